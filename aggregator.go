@@ -0,0 +1,226 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrAggregatorClosed 合并发送已关闭
+var ErrAggregatorClosed = errors.New("群机器人合并发送已关闭")
+
+// AggregatorConfig 合并发送配置
+type AggregatorConfig struct {
+	Window   time.Duration // 合并窗口，首条消息到达后开始计时，默认10秒
+	MaxCount int           // 单次最多合并的消息数，达到后立即发送，默认20
+	Title    string        // 合并消息的标题，默认"消息汇总"
+	OnError  func(error)   // (可选)窗口到期自动发送失败时的回调
+}
+
+// RobotAggregator 群机器人-合并发送
+//
+// 窗口期内的Text/Markdown消息合并为一条Markdown消息发送，避免触发频率限制
+type RobotAggregator struct {
+	robot *RobotCustom
+	cfg   AggregatorConfig
+
+	mu     sync.Mutex
+	items  []string // 待合并的消息内容
+	at     *RobotAt // 合并后的@人设置
+	timer  *time.Timer
+	closed bool
+}
+
+// NewRobotAggregator 实例化
+//
+// 示例:
+//
+//	agg := dingtalk.NewRobotAggregator(robot, dingtalk.AggregatorConfig{Window: 10 * time.Second})
+//	defer agg.Close(context.Background())
+//	agg.SendText("TEST: Aggregator")
+func NewRobotAggregator(rc *RobotCustom, cfg AggregatorConfig) *RobotAggregator {
+	if cfg.Window <= 0 {
+		cfg.Window = 10 * time.Second
+	}
+	if cfg.MaxCount <= 0 {
+		cfg.MaxCount = 20
+	}
+	if cfg.Title == "" {
+		cfg.Title = "消息汇总"
+	}
+
+	return &RobotAggregator{
+		robot: rc,
+		cfg:   cfg,
+	}
+}
+
+// SendText 添加一条Text消息，达到合并数量时立即发送
+//
+// 内容经 EscapeMarkdown 转义后合并，按原样展示；被@人的@标记保持不变，以便提醒
+func (ra *RobotAggregator) SendText(content string, opts ...RobotOption) error {
+	msg := NewTextMessage(content).With(opts...)
+	return ra.add(escapeMarkdownMentions(content, msg.At), msg, nil)
+}
+
+// SendMarkdown 添加一条Markdown消息，达到合并数量时立即发送
+func (ra *RobotAggregator) SendMarkdown(title, text string, opts ...RobotOption) error {
+	return ra.add(fmt.Sprintf("**%s**\n\n%s", title, text), NewMarkdownMessage(title, text), opts)
+}
+
+// Len 待合并的消息数
+func (ra *RobotAggregator) Len() int {
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+
+	return len(ra.items)
+}
+
+// Flush 立即发送待合并的消息
+func (ra *RobotAggregator) Flush(ctx context.Context) error {
+	ra.mu.Lock()
+	msg := ra.take()
+	ra.mu.Unlock()
+
+	return ra.deliver(ctx, msg)
+}
+
+// Close 发送待合并的消息，并停止接收新消息
+func (ra *RobotAggregator) Close(ctx context.Context) error {
+	ra.mu.Lock()
+	ra.closed = true
+	msg := ra.take()
+	ra.mu.Unlock()
+
+	return ra.deliver(ctx, msg)
+}
+
+// 添加消息
+func (ra *RobotAggregator) add(item string, msg *Message, opts []RobotOption) error {
+	if err := ra.robot.checkOptions(context.Background(), msg.With(opts...)); err != nil {
+		return err
+	}
+
+	ra.mu.Lock()
+	if ra.closed {
+		ra.mu.Unlock()
+		return ErrAggregatorClosed
+	}
+
+	ra.items = append(ra.items, item)
+	if msg.At != nil {
+		if ra.at == nil {
+			ra.at = &RobotAt{}
+		}
+		ra.at.IsAtAll = ra.at.IsAtAll || msg.At.IsAtAll
+		ra.at.AtMobiles = appendUnique(ra.at.AtMobiles, msg.At.AtMobiles...)
+		ra.at.AtUserIDs = appendUnique(ra.at.AtUserIDs, msg.At.AtUserIDs...)
+	}
+
+	var full *Message
+	if len(ra.items) >= ra.cfg.MaxCount {
+		full = ra.take()
+	} else if ra.timer == nil {
+		ra.timer = time.AfterFunc(ra.cfg.Window, ra.expire)
+	}
+	ra.mu.Unlock()
+
+	return ra.deliver(context.Background(), full)
+}
+
+// 窗口到期自动发送
+func (ra *RobotAggregator) expire() {
+	ra.mu.Lock()
+	msg := ra.take()
+	ra.mu.Unlock()
+
+	if err := ra.deliver(context.Background(), msg); err != nil && ra.cfg.OnError != nil {
+		ra.cfg.OnError(err)
+	}
+}
+
+// 取出待合并的消息并生成合并消息，调用方需持有锁
+func (ra *RobotAggregator) take() *Message {
+	if ra.timer != nil {
+		ra.timer.Stop()
+		ra.timer = nil
+	}
+	if len(ra.items) == 0 {
+		return nil
+	}
+
+	msg := NewMarkdownMessage(ra.cfg.Title, ra.merge(ra.items))
+	msg.At = ra.at
+	ra.items, ra.at = nil, nil
+	return msg
+}
+
+// 合并消息内容，超出长度限制时截断
+func (ra *RobotAggregator) merge(items []string) string {
+	const reserve = 64 // 为省略提示预留的长度
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("#### %s (共%d条)\n\n", ra.cfg.Title, len(items)))
+	for i, item := range items {
+		entry := fmt.Sprintf("%d. %s\n\n", i+1, item)
+		if b.Len()+len(entry) > maxContentBytes-reserve {
+			if i == 0 {
+				b.WriteString(truncateBytes(entry, maxContentBytes-reserve-b.Len()))
+				i++
+			}
+			if rest := len(items) - i; rest > 0 {
+				b.WriteString(fmt.Sprintf("\n\n...另有%d条消息已省略", rest))
+			}
+			break
+		}
+		b.WriteString(entry)
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// 发送合并消息
+func (ra *RobotAggregator) deliver(ctx context.Context, msg *Message) error {
+	if msg == nil {
+		return nil
+	}
+	return ra.robot.deliver(ctx, msg)
+}
+
+// 截断字符串至n字节以内，不截断UTF-8字符
+func truncateBytes(s string, n int) string {
+	const ellipsis = "..."
+	if len(s) <= n {
+		return s
+	}
+	if n <= len(ellipsis) {
+		return ""
+	}
+
+	n -= len(ellipsis)
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + ellipsis
+}
+
+// 追加不重复的元素
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		var exists bool
+		for _, v := range list {
+			if v == item {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			list = append(list, item)
+		}
+	}
+	return list
+}
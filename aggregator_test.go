@@ -0,0 +1,105 @@
+package dingtalk_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shockerli/dingtalk"
+)
+
+// 记录收到的消息
+type recorder struct {
+	mu       sync.Mutex
+	messages []map[string]interface{}
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	var msg map[string]interface{}
+	_ = json.Unmarshal(body, &msg)
+
+	rec.mu.Lock()
+	rec.messages = append(rec.messages, msg)
+	rec.mu.Unlock()
+
+	_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+}
+
+func (rec *recorder) all() []map[string]interface{} {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]map[string]interface{}{}, rec.messages...)
+}
+
+func TestRobotAggregator(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	rc := dingtalk.NewRobotCustom().SetWebhook(srv.URL + "/robot/send?access_token=test")
+	agg := dingtalk.NewRobotAggregator(rc, dingtalk.AggregatorConfig{Window: time.Hour, MaxCount: 3})
+
+	_ = agg.SendText("first <b>[x](y) @19900003333", rc.AtMobiles("19900001111"))
+	_ = agg.SendText("second @19900002222 @manager_1234", rc.AtMobiles("19900002222"), rc.AtUserIDs("manager_1234"))
+	if agg.Len() != 2 || len(rec.all()) != 0 {
+		t.Fatalf("messages sent before MaxCount reached")
+	}
+	if err := agg.SendMarkdown("third", "**content**"); err != nil {
+		t.Fatalf("SendMarkdown() error = %v", err)
+	}
+
+	messages := rec.all()
+	if len(messages) != 1 {
+		t.Fatalf("len(messages) = %d, want 1", len(messages))
+	}
+	md := messages[0]["markdown"].(map[string]interface{})
+	text := md["text"].(string)
+	for _, want := range []string{"共3条", "1. first &lt;b&gt;\\[x\\]\\(y\\) @\u200b19900003333", "2. second @19900002222 @manager_1234", "**third**"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("merged text %q does not contain %q", text, want)
+		}
+	}
+	at := messages[0]["at"].(map[string]interface{})
+	if mobiles := at["atMobiles"].([]interface{}); len(mobiles) != 2 {
+		t.Errorf("atMobiles = %v, want 2 mobiles", mobiles)
+	}
+	if ids := at["atUserIds"].([]interface{}); len(ids) != 1 {
+		t.Errorf("atUserIds = %v, want 1 user id", ids)
+	}
+}
+
+func TestRobotAggregator_Window(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	rc := dingtalk.NewRobotCustom().SetWebhook(srv.URL + "/robot/send?access_token=test")
+	agg := dingtalk.NewRobotAggregator(rc, dingtalk.AggregatorConfig{Window: 20 * time.Millisecond})
+
+	_ = agg.SendText(strings.Repeat("a", 15000))
+	_ = agg.SendText(strings.Repeat("b", 15000))
+	time.Sleep(100 * time.Millisecond)
+
+	messages := rec.all()
+	if len(messages) != 1 {
+		t.Fatalf("len(messages) = %d, want 1", len(messages))
+	}
+	text := messages[0]["markdown"].(map[string]interface{})["text"].(string)
+	if len(text) > 20000 || !strings.Contains(text, "另有1条消息已省略") {
+		t.Errorf("merged text not truncated, len = %d", len(text))
+	}
+
+	if err := agg.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := agg.SendText("closed"); err != dingtalk.ErrAggregatorClosed {
+		t.Errorf("SendText() after Close() error = %v, want %v", err, dingtalk.ErrAggregatorClosed)
+	}
+}
@@ -0,0 +1,225 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// 异步队列错误
+var (
+	ErrQueueFull   = errors.New("群机器人异步队列已满")
+	ErrQueueClosed = errors.New("群机器人异步队列已关闭")
+)
+
+// AsyncConfig 异步发送配置
+type AsyncConfig struct {
+	Workers   int                // 并发发送数，默认1
+	QueueSize int                // 队列容量，默认100
+	Callback  func(AsyncResult)  // (可选)发送结果回调，在发送协程中调用
+	Results   chan<- AsyncResult // (可选)发送结果通道，通道已满时丢弃结果
+}
+
+// AsyncResult 异步发送结果
+type AsyncResult struct {
+	MsgType string   // 消息类型
+	Message *Message // 发送的消息(已应用配置项)，Enqueue时为传入消息的副本
+	Err     error    // 发送错误，成功时为nil
+}
+
+// RobotAsync 群机器人-异步发送
+//
+// 消息进入有界队列，由多个协程并发发送，不阻塞调用方
+type RobotAsync struct {
+	robot *RobotCustom
+	cfg   AsyncConfig
+	queue chan asyncJob
+
+	mu     sync.RWMutex
+	closed bool
+
+	pendingMu sync.Mutex
+	pending   int             // 已入队但未发送完成的消息数
+	idle      []chan struct{} // 等待队列清空的Flush调用
+
+	workers sync.WaitGroup
+	ctx     context.Context // 发送消息使用的上下文，Close超时时取消
+	cancel  context.CancelFunc
+}
+
+// 异步发送任务
+type asyncJob struct {
+	msg  *Message
+	opts []RobotOption
+}
+
+// NewRobotAsync 实例化，并启动发送协程
+//
+// 示例:
+// 	async := dingtalk.NewRobotAsync(robot, dingtalk.AsyncConfig{Workers: 2, QueueSize: 1000})
+// 	defer async.Close(context.Background())
+// 	async.SendText("TEST: Async")
+func NewRobotAsync(rc *RobotCustom, cfg AsyncConfig) *RobotAsync {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+
+	ra := &RobotAsync{
+		robot: rc,
+		cfg:   cfg,
+		queue: make(chan asyncJob, cfg.QueueSize),
+	}
+	ra.ctx, ra.cancel = context.WithCancel(context.Background())
+
+	ra.workers.Add(cfg.Workers)
+	for i := 0; i < cfg.Workers; i++ {
+		go ra.work()
+	}
+
+	return ra
+}
+
+// SendText 异步发送Text消息
+func (ra *RobotAsync) SendText(content string, opts ...RobotOption) error {
+	return ra.enqueue(NewTextMessage(content), opts)
+}
+
+// SendLink 异步发送Link消息
+func (ra *RobotAsync) SendLink(title, text, msgURL, picURL string, opts ...RobotOption) error {
+	return ra.enqueue(NewLinkMessage(title, text, msgURL, picURL), opts)
+}
+
+// SendMarkdown 异步发送Markdown消息
+func (ra *RobotAsync) SendMarkdown(title, text string, opts ...RobotOption) error {
+	return ra.enqueue(NewMarkdownMessage(title, text), opts)
+}
+
+// SendActionCard 异步发送ActionCard消息
+func (ra *RobotAsync) SendActionCard(title, text string, opts ...RobotOption) error {
+	return ra.enqueue(NewActionCardMessage(title, text), opts)
+}
+
+// SendFeedCard 异步发送FeedCard消息
+func (ra *RobotAsync) SendFeedCard(opts ...RobotOption) error {
+	return ra.enqueue(NewFeedCardMessage(), opts)
+}
+
+// Enqueue 异步发送已构造的消息，msg本身不会被修改
+func (ra *RobotAsync) Enqueue(msg *Message) error {
+	return ra.enqueue(msg.clone(), nil)
+}
+
+// Len 队列中等待发送的消息数
+func (ra *RobotAsync) Len() int {
+	return len(ra.queue)
+}
+
+// Flush 等待已入队的消息全部发送完成
+func (ra *RobotAsync) Flush(ctx context.Context) error {
+	ra.pendingMu.Lock()
+	if ra.pending == 0 {
+		ra.pendingMu.Unlock()
+		return nil
+	}
+	idle := make(chan struct{})
+	ra.idle = append(ra.idle, idle)
+	ra.pendingMu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close 停止接收新消息，并等待已入队的消息发送完成
+//
+// ctx结束时取消正在发送的消息，剩余消息以错误结果返回
+func (ra *RobotAsync) Close(ctx context.Context) error {
+	ra.mu.Lock()
+	if !ra.closed {
+		ra.closed = true
+		close(ra.queue)
+	}
+	ra.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		ra.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		ra.cancel()
+		return nil
+	case <-ctx.Done():
+		ra.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// 消息入队
+func (ra *RobotAsync) enqueue(msg *Message, opts []RobotOption) error {
+	ra.mu.RLock()
+	defer ra.mu.RUnlock()
+
+	if ra.closed {
+		return ErrQueueClosed
+	}
+
+	ra.pendingMu.Lock()
+	ra.pending++
+	ra.pendingMu.Unlock()
+
+	select {
+	case ra.queue <- asyncJob{msg: msg, opts: opts}:
+		return nil
+	default:
+		ra.done()
+		return ErrQueueFull
+	}
+}
+
+// 发送协程
+func (ra *RobotAsync) work() {
+	defer ra.workers.Done()
+
+	for job := range ra.queue {
+		err := ra.robot.send(ra.ctx, job.msg, job.opts...)
+		ra.report(AsyncResult{MsgType: job.msg.MsgType, Message: job.msg, Err: err})
+		ra.done()
+	}
+}
+
+// 反馈发送结果
+func (ra *RobotAsync) report(res AsyncResult) {
+	if ra.cfg.Callback != nil {
+		ra.cfg.Callback(res)
+	}
+	if ra.cfg.Results != nil {
+		select {
+		case ra.cfg.Results <- res:
+		default:
+		}
+	}
+}
+
+// 一条消息处理完成
+func (ra *RobotAsync) done() {
+	ra.pendingMu.Lock()
+	defer ra.pendingMu.Unlock()
+
+	ra.pending--
+	if ra.pending == 0 {
+		for _, idle := range ra.idle {
+			close(idle)
+		}
+		ra.idle = nil
+	}
+}
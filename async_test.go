@@ -0,0 +1,69 @@
+package dingtalk_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shockerli/dingtalk"
+)
+
+func TestRobotAsync(t *testing.T) {
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook("https://oapi.dingtalk.com/robot/send?access_token=test").
+		SetTransport(okTransport())
+
+	var succeeded int32
+	results := make(chan dingtalk.AsyncResult, 10)
+	var failed atomic.Value
+	async := dingtalk.NewRobotAsync(rc, dingtalk.AsyncConfig{
+		Workers:   2,
+		QueueSize: 10,
+		Callback: func(res dingtalk.AsyncResult) {
+			if res.Err == nil {
+				atomic.AddInt32(&succeeded, 1)
+				return
+			}
+			failed.Store(res.Message)
+		},
+		Results: results,
+	})
+
+	for i := 0; i < 5; i++ {
+		if err := async.SendText("TEST: Async"); err != nil {
+			t.Fatalf("SendText() error = %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := async.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&succeeded); got != 5 {
+		t.Errorf("succeeded = %d, want 5", got)
+	}
+	if got := len(results); got != 5 {
+		t.Errorf("len(results) = %d, want 5", got)
+	}
+
+	// 发送失败的结果携带对应的消息
+	if err := async.SendLink("TEST: Async", "", "", "", rc.AtAll()); err != nil {
+		t.Fatalf("SendLink() error = %v", err)
+	}
+	if err := async.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if msg, _ := failed.Load().(*dingtalk.Message); msg == nil || msg.Link == nil || msg.Link.Title != "TEST: Async" {
+		t.Errorf("failed result message = %+v", msg)
+	}
+
+	if err := async.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := async.SendText("TEST: Async"); !errors.Is(err, dingtalk.ErrQueueClosed) {
+		t.Errorf("SendText() after Close() error = %v, want %v", err, dingtalk.ErrQueueClosed)
+	}
+}
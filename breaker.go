@@ -0,0 +1,157 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 熔断中，消息未发送
+var ErrCircuitOpen = errors.New("群机器人熔断中，暂停发送")
+
+// CircuitState 熔断器状态
+type CircuitState int
+
+// 熔断器状态
+const (
+	CircuitClosed   CircuitState = iota // 关闭: 正常发送
+	CircuitOpen                         // 打开: 直接返回 ErrCircuitOpen
+	CircuitHalfOpen                     // 半开: 允许少量试探请求
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreakerConfig 熔断器配置
+type CircuitBreakerConfig struct {
+	FailureThreshold int              // 连续失败次数达到该值时熔断，默认5
+	CoolDown         time.Duration    // 熔断持续时间，到期后进入半开状态，默认30秒
+	HalfOpenMax      int              // 半开状态下允许同时进行的试探请求数，默认1
+	IsFailure        func(error) bool // 计为失败的错误，默认 IsTemporary
+}
+
+// CircuitBreaker 熔断器
+//
+// 连续失败达到阈值后熔断，冷却期内直接返回 ErrCircuitOpen；
+// 冷却期结束后进入半开状态，试探请求成功则恢复，失败则重新熔断
+type CircuitBreaker struct {
+	mu       sync.Mutex
+	cfg      CircuitBreakerConfig
+	state    CircuitState
+	failures int       // 连续失败次数
+	openedAt time.Time // 熔断开始时间
+	probes   int       // 进行中的试探请求数
+	gen      uint64    // 状态变更次数，用于忽略状态变更前放行的请求
+}
+
+// 放行请求时的状态
+type breakerToken struct {
+	gen   uint64
+	probe bool // 是否为试探请求
+}
+
+// NewCircuitBreaker 实例化
+//
+// 示例:
+//
+//	cb := dingtalk.NewCircuitBreaker(dingtalk.CircuitBreakerConfig{FailureThreshold: 5, CoolDown: 30 * time.Second})
+func NewCircuitBreaker(cfg CircuitBreakerConfig) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.CoolDown <= 0 {
+		cfg.CoolDown = 30 * time.Second
+	}
+	if cfg.HalfOpenMax <= 0 {
+		cfg.HalfOpenMax = 1
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = IsTemporary
+	}
+
+	return &CircuitBreaker{cfg: cfg}
+}
+
+// State 当前状态
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.transition(time.Now())
+	return cb.state
+}
+
+// 是否允许发送，返回的token需传给 record
+func (cb *CircuitBreaker) allow() (breakerToken, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.transition(time.Now())
+	t := breakerToken{gen: cb.gen}
+	switch cb.state {
+	case CircuitOpen:
+		return t, ErrCircuitOpen
+	case CircuitHalfOpen:
+		if cb.probes >= cb.cfg.HalfOpenMax {
+			return t, ErrCircuitOpen
+		}
+		cb.probes++
+		t.probe = true
+	}
+	return t, nil
+}
+
+// 记录发送结果，放行后状态已变更的请求不影响状态
+func (cb *CircuitBreaker) record(t breakerToken, err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if t.gen != cb.gen {
+		return
+	}
+	if t.probe {
+		cb.probes--
+	}
+
+	// 调用方取消或本地限流，消息未到达服务端，不影响状态
+	if errors.Is(err, context.Canceled) || isLocalLimit(err) {
+		return
+	}
+
+	if err != nil && cb.cfg.IsFailure(err) {
+		cb.failures++
+		if t.probe || cb.failures >= cb.cfg.FailureThreshold {
+			cb.state = CircuitOpen
+			cb.openedAt = time.Now()
+			cb.probes = 0
+			cb.gen++
+		}
+		return
+	}
+
+	cb.failures = 0
+	if t.probe {
+		cb.state = CircuitClosed
+		cb.probes = 0
+		cb.gen++
+	}
+}
+
+// 冷却期结束后进入半开状态
+func (cb *CircuitBreaker) transition(now time.Time) {
+	if cb.state == CircuitOpen && now.Sub(cb.openedAt) >= cb.cfg.CoolDown {
+		cb.state = CircuitHalfOpen
+		cb.probes = 0
+		cb.gen++
+	}
+}
@@ -0,0 +1,173 @@
+package dingtalk_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shockerli/dingtalk"
+	"github.com/shockerli/dingtalk/dingtalktest"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var healthy int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer srv.Close()
+
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook(srv.URL + "/robot/send?access_token=test").
+		SetCircuitBreaker(dingtalk.NewCircuitBreaker(dingtalk.CircuitBreakerConfig{
+			FailureThreshold: 2,
+			CoolDown:         50 * time.Millisecond,
+		}))
+
+	for i := 0; i < 2; i++ {
+		_ = rc.SendText("TEST: CircuitBreaker")
+	}
+	if rc.CircuitState() != dingtalk.CircuitOpen {
+		t.Fatalf("CircuitState() = %v, want %v", rc.CircuitState(), dingtalk.CircuitOpen)
+	}
+	if err := rc.SendText("TEST: CircuitBreaker"); !errors.Is(err, dingtalk.ErrCircuitOpen) {
+		t.Fatalf("SendText() error = %v, want %v", err, dingtalk.ErrCircuitOpen)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if rc.CircuitState() != dingtalk.CircuitHalfOpen {
+		t.Fatalf("CircuitState() = %v, want %v", rc.CircuitState(), dingtalk.CircuitHalfOpen)
+	}
+	atomic.StoreInt32(&healthy, 1)
+	if err := rc.SendText("TEST: CircuitBreaker"); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+	if rc.CircuitState() != dingtalk.CircuitClosed {
+		t.Errorf("CircuitState() = %v, want %v", rc.CircuitState(), dingtalk.CircuitClosed)
+	}
+}
+
+func TestCircuitBreaker_RateLimited(t *testing.T) {
+	srv := dingtalktest.NewServer("")
+	defer srv.Close()
+	srv.Respond(dingtalktest.Response{StatusCode: http.StatusBadGateway})
+
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook(srv.Webhook()).
+		SetRateLimit(1, time.Minute, dingtalk.RateLimitFailFast).
+		SetCircuitBreaker(dingtalk.NewCircuitBreaker(dingtalk.CircuitBreakerConfig{
+			FailureThreshold: 1,
+			CoolDown:         50 * time.Millisecond,
+		}))
+
+	if err := rc.SendText("TEST: Breaker"); err == nil {
+		t.Fatal("SendText() error = nil, want 502")
+	}
+	if got := rc.CircuitState(); got != dingtalk.CircuitOpen {
+		t.Fatalf("CircuitState() = %v, want open", got)
+	}
+
+	// 半开状态下的试探请求被本地限流，未到达服务端，不恢复
+	time.Sleep(60 * time.Millisecond)
+	if err := rc.SendText("TEST: Breaker"); !errors.Is(err, dingtalk.ErrQuotaExceeded) {
+		t.Fatalf("SendText() error = %v, want ErrQuotaExceeded", err)
+	}
+	if got := rc.CircuitState(); got != dingtalk.CircuitHalfOpen {
+		t.Errorf("CircuitState() = %v, want half-open", got)
+	}
+}
+
+func TestCircuitBreaker_RateLimitWait(t *testing.T) {
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook("https://oapi.dingtalk.com/robot/send?access_token=test").
+		SetTransport(okTransport()).
+		SetRateLimit(1, time.Minute, dingtalk.RateLimitBlock).
+		SetCircuitBreaker(dingtalk.NewCircuitBreaker(dingtalk.CircuitBreakerConfig{FailureThreshold: 2}))
+
+	if err := rc.SendText("TEST: Breaker"); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+
+	// 等待本地限流额度超时，未到达服务端，不计为失败
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		err := rc.Send(ctx, dingtalk.NewTextMessage("TEST: Breaker"))
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Send() error = %v, want context.DeadlineExceeded", err)
+		}
+	}
+	if got := rc.CircuitState(); got != dingtalk.CircuitClosed {
+		t.Errorf("CircuitState() = %v, want closed", got)
+	}
+}
+
+func TestCircuitBreaker_StaleRequest(t *testing.T) {
+	received := make(chan string, 4)
+	release := map[string]chan struct{}{"slow 1": make(chan struct{}), "slow 2": make(chan struct{})}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- string(body)
+		switch {
+		case strings.Contains(string(body), "fail"):
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		case strings.Contains(string(body), "slow 1"):
+			<-release["slow 1"]
+		case strings.Contains(string(body), "slow 2"):
+			<-release["slow 2"]
+		}
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer srv.Close()
+
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook(srv.URL + "/robot/send?access_token=test").
+		SetCircuitBreaker(dingtalk.NewCircuitBreaker(dingtalk.CircuitBreakerConfig{
+			FailureThreshold: 1,
+			CoolDown:         20 * time.Millisecond,
+		}))
+
+	// 关闭状态下放行的请求，在熔断后才返回
+	slow := make(chan error, 2)
+	go func() { slow <- rc.SendText("TEST: slow 1") }()
+	<-received
+	if err := rc.SendText("TEST: fail"); err == nil {
+		t.Fatal("SendText() error = nil, want 502")
+	}
+	<-received
+
+	// 半开状态下的试探请求进行中
+	time.Sleep(30 * time.Millisecond)
+	go func() { slow <- rc.SendText("TEST: slow 2") }()
+	<-received
+	close(release["slow 1"])
+	if err := <-slow; err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+
+	// 之前放行的请求不影响试探状态
+	if got := rc.CircuitState(); got != dingtalk.CircuitHalfOpen {
+		t.Errorf("CircuitState() = %v, want half-open", got)
+	}
+	if err := rc.SendText("TEST: probe"); !errors.Is(err, dingtalk.ErrCircuitOpen) {
+		t.Errorf("SendText() error = %v, want ErrCircuitOpen", err)
+	}
+
+	close(release["slow 2"])
+	if err := <-slow; err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+	if got := rc.CircuitState(); got != dingtalk.CircuitClosed {
+		t.Errorf("CircuitState() = %v, want closed", got)
+	}
+}
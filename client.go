@@ -3,42 +3,56 @@ package dingtalk
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
+	"regexp"
 	"time"
 )
 
+// 默认请求超时时间
+const defaultTimeout = 2 * time.Second
+
 var httpClient *http.Client
 
 func init() {
 	httpClient = &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-			DialContext: (&net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: 30 * time.Second,
-			}).DialContext,
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 100,
-			IdleConnTimeout:     90 * time.Second,
-		},
+		Transport: newTransport(),
 	}
 }
 
-func request(url string, body []byte) (data []byte, err error) {
+// 默认Transport配置
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 100,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// 发起请求，超时时间取ctx截止时间与timeout中较早者
+func request(ctx context.Context, client *http.Client, timeout time.Duration, url string, body []byte) (data []byte, resp *http.Response, err error) {
 
 	// timeout context
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
 	if err != nil {
+		err = redactError(err)
 		return
 	}
 	req.Header.Set("Content-type", "application/json")
-	resp, err := httpClient.Do(req)
+	resp, err = client.Do(req)
 	if err != nil {
+		err = redactError(err)
 		return
 	}
 
@@ -51,3 +65,21 @@ func request(url string, body []byte) (data []byte, err error) {
 
 	return
 }
+
+// URL中需脱敏的参数
+var sensitiveParams = regexp.MustCompile(`([?&](?:access_token|sign|session)=)[^&#"]*`)
+
+// 隐藏URL中的access_token、sign等敏感参数
+func redactURL(s string) string {
+	return sensitiveParams.ReplaceAllString(s, "${1}REDACTED")
+}
+
+// 隐藏错误中URL的敏感参数，保留原始错误链
+func redactError(err error) error {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if ue, ok := e.(*url.Error); ok {
+			ue.URL = redactURL(ue.URL)
+		}
+	}
+	return err
+}
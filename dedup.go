@@ -0,0 +1,222 @@
+package dingtalk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DedupConfig 重复消息抑制配置
+type DedupConfig struct {
+	TTL      time.Duration // 去重窗口，窗口内相同的消息只发送一次，默认5分钟
+	Repeated bool          // 窗口结束时，若存在被抑制的消息，发送"重复N次"的提醒
+	OnError  func(error)   // (可选)提醒发送失败时的回调
+}
+
+// RobotDedup 群机器人-重复消息抑制
+//
+// 消息类型、标题、内容及@人设置均相同，且发往同一Outgoing临时会话(或均非临时会话)的消息视为重复消息
+type RobotDedup struct {
+	robot *RobotCustom
+	cfg   DedupConfig
+
+	mu      sync.Mutex
+	entries map[string]*dedupEntry
+}
+
+// 去重窗口内的消息
+type dedupEntry struct {
+	summary  string        // 消息摘要
+	outgoing RobotOutgoing // 临时会话，"重复N次"的提醒发往同一会话
+	count    int           // 被抑制的次数
+	sent     chan struct{} // 首条消息发送完成时关闭
+	timer    *time.Timer   // 发送成功后开始计时
+}
+
+// NewRobotDedup 实例化
+//
+// 示例:
+// 	dedup := dingtalk.NewRobotDedup(robot, dingtalk.DedupConfig{TTL: 5 * time.Minute, Repeated: true})
+// 	dedup.SendText("TEST: Dedup")
+func NewRobotDedup(rc *RobotCustom, cfg DedupConfig) *RobotDedup {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 5 * time.Minute
+	}
+
+	return &RobotDedup{
+		robot:   rc,
+		cfg:     cfg,
+		entries: make(map[string]*dedupEntry),
+	}
+}
+
+// SendText 发送Text消息，重复消息将被抑制
+func (rd *RobotDedup) SendText(content string, opts ...RobotOption) error {
+	return rd.SendTextContext(context.Background(), content, opts...)
+}
+
+// SendTextContext 发送Text消息，请求受ctx的超时及取消控制
+func (rd *RobotDedup) SendTextContext(ctx context.Context, content string, opts ...RobotOption) error {
+	return rd.send(ctx, NewTextMessage(content), opts...)
+}
+
+// SendLink 发送Link消息，重复消息将被抑制
+func (rd *RobotDedup) SendLink(title, text, msgURL, picURL string, opts ...RobotOption) error {
+	return rd.SendLinkContext(context.Background(), title, text, msgURL, picURL, opts...)
+}
+
+// SendLinkContext 发送Link消息，请求受ctx的超时及取消控制
+func (rd *RobotDedup) SendLinkContext(ctx context.Context, title, text, msgURL, picURL string, opts ...RobotOption) error {
+	return rd.send(ctx, NewLinkMessage(title, text, msgURL, picURL), opts...)
+}
+
+// SendMarkdown 发送Markdown消息，重复消息将被抑制
+func (rd *RobotDedup) SendMarkdown(title, text string, opts ...RobotOption) error {
+	return rd.SendMarkdownContext(context.Background(), title, text, opts...)
+}
+
+// SendMarkdownContext 发送Markdown消息，请求受ctx的超时及取消控制
+func (rd *RobotDedup) SendMarkdownContext(ctx context.Context, title, text string, opts ...RobotOption) error {
+	return rd.send(ctx, NewMarkdownMessage(title, text), opts...)
+}
+
+// SendActionCard 发送ActionCard消息，重复消息将被抑制
+func (rd *RobotDedup) SendActionCard(title, text string, opts ...RobotOption) error {
+	return rd.SendActionCardContext(context.Background(), title, text, opts...)
+}
+
+// SendActionCardContext 发送ActionCard消息，请求受ctx的超时及取消控制
+func (rd *RobotDedup) SendActionCardContext(ctx context.Context, title, text string, opts ...RobotOption) error {
+	return rd.send(ctx, NewActionCardMessage(title, text), opts...)
+}
+
+// SendFeedCard 发送FeedCard消息，重复消息将被抑制
+func (rd *RobotDedup) SendFeedCard(opts ...RobotOption) error {
+	return rd.SendFeedCardContext(context.Background(), opts...)
+}
+
+// SendFeedCardContext 发送FeedCard消息，请求受ctx的超时及取消控制
+func (rd *RobotDedup) SendFeedCardContext(ctx context.Context, opts ...RobotOption) error {
+	return rd.send(ctx, NewFeedCardMessage(), opts...)
+}
+
+// Send 发送已构造的消息，重复消息将被抑制，msg本身不会被修改
+func (rd *RobotDedup) Send(ctx context.Context, msg *Message) error {
+	return rd.send(ctx, msg.clone())
+}
+
+// Close 立即结束所有去重窗口，并发送"重复N次"的提醒
+func (rd *RobotDedup) Close(ctx context.Context) error {
+	rd.mu.Lock()
+	entries := rd.entries
+	rd.entries = make(map[string]*dedupEntry)
+	rd.mu.Unlock()
+
+	var err error
+	for _, entry := range entries {
+		if entry.timer != nil {
+			entry.timer.Stop()
+		}
+		if e := rd.repeated(ctx, entry); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// 发送消息，窗口内的重复消息直接返回
+func (rd *RobotDedup) send(ctx context.Context, msg *Message, opts ...RobotOption) error {
+	if err := rd.robot.checkOptions(ctx, msg.With(opts...)); err != nil {
+		return err
+	}
+
+	key, err := dedupKey(msg)
+	if err != nil {
+		return err
+	}
+
+	// 相同的消息正在发送时等待其结果，发送失败则由本条消息重新发送
+	rd.mu.Lock()
+	for {
+		entry, ok := rd.entries[key]
+		if !ok {
+			break
+		}
+		select {
+		case <-entry.sent:
+			entry.count++
+			rd.mu.Unlock()
+			return nil
+		default:
+		}
+		rd.mu.Unlock()
+
+		select {
+		case <-entry.sent:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		rd.mu.Lock()
+	}
+	entry := &dedupEntry{summary: msg.summary(), outgoing: msg.outgoing, sent: make(chan struct{})}
+	rd.entries[key] = entry
+	rd.mu.Unlock()
+
+	err = rd.robot.deliver(ctx, msg)
+
+	// 发送失败时不抑制后续相同的消息
+	rd.mu.Lock()
+	if rd.entries[key] == entry {
+		if err != nil {
+			delete(rd.entries, key)
+		} else {
+			entry.timer = time.AfterFunc(rd.cfg.TTL, func() { rd.expire(key, entry) })
+		}
+	}
+	close(entry.sent)
+	rd.mu.Unlock()
+	return err
+}
+
+// 去重窗口结束
+func (rd *RobotDedup) expire(key string, entry *dedupEntry) {
+	rd.mu.Lock()
+	if rd.entries[key] != entry {
+		rd.mu.Unlock()
+		return
+	}
+	delete(rd.entries, key)
+	rd.mu.Unlock()
+
+	if err := rd.repeated(context.Background(), entry); err != nil && rd.cfg.OnError != nil {
+		rd.cfg.OnError(err)
+	}
+}
+
+// 发送"重复N次"的提醒
+func (rd *RobotDedup) repeated(ctx context.Context, entry *dedupEntry) error {
+	rd.mu.Lock()
+	count := entry.count
+	rd.mu.Unlock()
+
+	if !rd.cfg.Repeated || count == 0 {
+		return nil
+	}
+	msg := NewTextMessage(fmt.Sprintf("%s\n(以上消息在%v内重复%d次)", entry.summary, rd.cfg.TTL, count))
+	msg.outgoing = entry.outgoing
+	return rd.robot.deliver(ctx, msg)
+}
+
+// 消息的去重标识，区分Outgoing临时会话
+func dedupKey(msg *Message) (string, error) {
+	v, err := json.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append(append(v, '\n'), msg.outgoing.SessionWebhook...))
+	return hex.EncodeToString(sum[:]), nil
+}
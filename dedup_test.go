@@ -0,0 +1,116 @@
+package dingtalk_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shockerli/dingtalk"
+	"github.com/shockerli/dingtalk/dingtalktest"
+)
+
+func TestRobotDedup(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	rc := dingtalk.NewRobotCustom().SetWebhook(srv.URL + "/robot/send?access_token=test")
+	dedup := dingtalk.NewRobotDedup(rc, dingtalk.DedupConfig{TTL: 50 * time.Millisecond, Repeated: true})
+
+	for i := 0; i < 3; i++ {
+		if err := dedup.SendText("TEST: Dedup"); err != nil {
+			t.Fatalf("SendText() error = %v", err)
+		}
+	}
+	// @人设置不同，不视为重复
+	if err := dedup.SendText("TEST: Dedup", rc.AtAll()); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+	if got := len(rec.all()); got != 2 {
+		t.Fatalf("len(messages) = %d, want 2", got)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	messages := rec.all()
+	if len(messages) != 3 {
+		t.Fatalf("len(messages) = %d, want 3", len(messages))
+	}
+	content := messages[2]["text"].(map[string]interface{})["content"].(string)
+	if !strings.Contains(content, "TEST: Dedup") || !strings.Contains(content, "重复2次") {
+		t.Errorf("repeated content = %q", content)
+	}
+}
+
+func TestRobotDedup_InFlightFailure(t *testing.T) {
+	srv := dingtalktest.NewServer("")
+	defer srv.Close()
+	srv.Respond(dingtalktest.Response{StatusCode: http.StatusBadGateway, Delay: 100 * time.Millisecond})
+
+	rc := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook())
+	dedup := dingtalk.NewRobotDedup(rc, dingtalk.DedupConfig{TTL: time.Minute})
+
+	first := make(chan error, 1)
+	go func() { first <- dedup.SendText("TEST: Dedup") }()
+	time.Sleep(30 * time.Millisecond)
+
+	// 首条消息发送失败后，重复的消息自行发送(预设的失败响应不计入 Messages)
+	if err := dedup.SendText("TEST: Dedup"); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+	if err := <-first; err == nil {
+		t.Fatal("first SendText() error = nil, want 502")
+	}
+	if n := len(srv.Messages()); n != 1 {
+		t.Errorf("len(Messages()) = %d, want 1", n)
+	}
+
+	// 发送成功后，重复的消息被抑制
+	if err := dedup.SendText("TEST: Dedup"); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+	if n := len(srv.Messages()); n != 1 {
+		t.Errorf("len(Messages()) = %d, want 1", n)
+	}
+}
+
+func TestRobotDedup_Outgoing(t *testing.T) {
+	var mu sync.Mutex
+	var sessions []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sessions = append(sessions, r.URL.Query().Get("session"))
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer srv.Close()
+
+	rc := dingtalk.NewRobotCustom().SetWebhook(srv.URL + "/robot/send?access_token=test")
+	dedup := dingtalk.NewRobotDedup(rc, dingtalk.DedupConfig{TTL: time.Hour, Repeated: true})
+	outgoing := func(session string) dingtalk.RobotOption {
+		return rc.WithOutgoing(dingtalk.RobotOutgoing{
+			SessionWebhook:            srv.URL + "/robot/sendBySession?session=" + session,
+			SessionWebhookExpiredTime: time.Now().Add(time.Hour).UnixNano() / 1e6,
+		})
+	}
+
+	// 不同会话的相同回复均发送，同一会话的重复回复被抑制
+	for _, session := range []string{"a", "b", "a"} {
+		if err := dedup.SendText("TEST: Dedup", outgoing(session)); err != nil {
+			t.Fatalf("SendText() error = %v", err)
+		}
+	}
+	// "重复N次"的提醒发往同一会话
+	if err := dedup.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got, want := strings.Join(sessions, ","), "a,b,a"; got != want {
+		t.Errorf("sessions = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,296 @@
+// Package dingtalktest 提供用于测试的模拟钉钉群机器人服务
+//
+// 示例:
+// 	srv := dingtalktest.NewServer("SECxxx")
+// 	defer srv.Close()
+//
+// 	robot := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SetSecret(srv.Secret)
+// 	robot.SendText("TEST: Text")
+//
+// 	srv.Messages() // 收到的消息
+package dingtalktest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 签名中的时间戳与服务端时间允许的最大误差
+const timestampWindow = time.Hour
+
+// Token 模拟服务接受的access_token
+const Token = "dingtalktest"
+
+// Session 模拟服务接受的Outgoing临时会话
+const Session = "dingtalktest"
+
+// Server 模拟钉钉群机器人服务
+//
+// 校验access_token及签名，记录收到的消息，并可预设响应、延迟及频率限制
+type Server struct {
+	URL    string // 服务地址
+	Secret string // 签名密钥，为空时不校验签名
+
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	messages  []Message
+	attempts  []Message     // 通过校验的请求，含使用预设响应及被限流的请求
+	responses []Response    // 预设的响应，按顺序使用
+	delay     time.Duration // 每次请求的延迟
+	limit     int           // 窗口内最多接受的消息数，0为不限制
+	window    time.Duration
+	accepted  []time.Time // 窗口内接受的消息时间
+}
+
+// Message 收到的消息
+type Message struct {
+	MsgType string      // 消息类型
+	Body    []byte      // 原始请求体
+	Query   url.Values  // 请求参数
+	Header  http.Header // 请求头
+	Time    time.Time   // 接收时间
+}
+
+// Decode 将消息体解析到v
+func (m Message) Decode(v interface{}) error {
+	return json.Unmarshal(m.Body, v)
+}
+
+// Response 预设的响应
+type Response struct {
+	StatusCode int           // HTTP状态码，默认200
+	ErrCode    int           // 错误码
+	ErrMsg     string        // 错误信息
+	Delay      time.Duration // 响应前的延迟
+}
+
+// NewServer 启动模拟服务，secret为空时不校验签名
+func NewServer(secret string) *Server {
+	s := &Server{Secret: secret}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Webhook 自定义机器人的Webhook
+func (s *Server) Webhook() string {
+	return s.URL + "/robot/send?access_token=" + Token
+}
+
+// SessionWebhook Outgoing临时会话的Webhook
+func (s *Server) SessionWebhook() string {
+	return s.URL + "/robot/sendBySession?session=" + Session
+}
+
+// Close 关闭服务
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Messages 已接受的消息
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message{}, s.messages...)
+}
+
+// Attempts 通过access_token及签名校验的请求，含使用预设响应及被限流的请求
+func (s *Server) Attempts() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message{}, s.attempts...)
+}
+
+// Reset 清空已接受的消息、请求记录、预设的响应及频率限制记录
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = nil
+	s.attempts = nil
+	s.responses = nil
+	s.accepted = nil
+}
+
+// Respond 预设接下来的响应，按顺序使用，用完后恢复正常响应
+//
+// 预设的响应仅用于通过access_token及签名校验的请求，请求记录在 Attempts 中
+//
+// 示例:
+// 	srv.Respond(
+// 		dingtalktest.Response{StatusCode: http.StatusBadGateway},
+// 		dingtalktest.Response{ErrCode: 310000, ErrMsg: "keywords not in content"},
+// 	)
+func (s *Server) Respond(rs ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responses = append(s.responses, rs...)
+}
+
+// SetDelay 设置每次请求的延迟
+func (s *Server) SetDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.delay = d
+}
+
+// SetRateLimit 设置频率限制，窗口window内超过limit条消息时返回限流错误
+func (s *Server) SetRateLimit(limit int, window time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.limit, s.window = limit, window
+	s.accepted = nil
+}
+
+// Sign 计算签名，与群机器人的签名算法一致
+func Sign(timestamp int64, secret string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	_, _ = h.Write([]byte(fmt.Sprintf("%d\n%s", timestamp, secret)))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// 处理请求
+//
+// 先校验请求并记录，再使用预设的响应或按频率限制处理
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	delay := s.delay
+	s.mu.Unlock()
+
+	if delay > 0 && !sleep(r, delay) {
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		reply(w, http.StatusBadRequest, 400, err.Error())
+		return
+	}
+	var msg struct {
+		MsgType string `json:"msgtype"`
+	}
+	if r.Method != http.MethodPost || json.Unmarshal(body, &msg) != nil || msg.MsgType == "" {
+		reply(w, http.StatusOK, 40035, "缺少参数 json")
+		return
+	}
+
+	query := r.URL.Query()
+	switch r.URL.Path {
+	case "/robot/send":
+		if query.Get("access_token") != Token {
+			reply(w, http.StatusOK, 300001, "token is not exist")
+			return
+		}
+		if s.Secret != "" {
+			if errMsg := s.verify(query); errMsg != "" {
+				reply(w, http.StatusOK, 310000, errMsg)
+				return
+			}
+		}
+	case "/robot/sendBySession":
+		if query.Get("session") != Session {
+			reply(w, http.StatusOK, 300001, "session is not exist")
+			return
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	now := time.Now()
+	received := Message{
+		MsgType: msg.MsgType,
+		Body:    body,
+		Query:   query,
+		Header:  r.Header.Clone(),
+		Time:    now,
+	}
+
+	s.mu.Lock()
+	s.attempts = append(s.attempts, received)
+	if len(s.responses) > 0 {
+		scripted := s.responses[0]
+		s.responses = s.responses[1:]
+		s.mu.Unlock()
+
+		if scripted.Delay > 0 && !sleep(r, scripted.Delay) {
+			return
+		}
+		status := scripted.StatusCode
+		if status == 0 {
+			status = http.StatusOK
+		}
+		reply(w, status, scripted.ErrCode, scripted.ErrMsg)
+		return
+	}
+
+	if s.limit > 0 {
+		i := 0
+		for i < len(s.accepted) && now.Sub(s.accepted[i]) >= s.window {
+			i++
+		}
+		s.accepted = s.accepted[i:]
+		if len(s.accepted) >= s.limit {
+			s.mu.Unlock()
+			reply(w, http.StatusOK, 410100, "send too fast, exceed 20 times per minute")
+			return
+		}
+		s.accepted = append(s.accepted, now)
+	}
+	s.messages = append(s.messages, received)
+	s.mu.Unlock()
+
+	reply(w, http.StatusOK, 0, "ok")
+}
+
+// 等待d，请求被取消时返回false
+func sleep(r *http.Request, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// 校验时间戳及签名，返回错误信息
+func (s *Server) verify(query url.Values) string {
+	ts, err := strconv.ParseInt(query.Get("timestamp"), 10, 64)
+	if err != nil {
+		return "invalid timestamp"
+	}
+	diff := time.Since(time.Unix(0, ts*int64(time.Millisecond)))
+	if diff > timestampWindow || diff < -timestampWindow {
+		return "invalid timestamp"
+	}
+	if !hmac.Equal([]byte(query.Get("sign")), []byte(Sign(ts, s.Secret))) {
+		return "sign not match"
+	}
+	return ""
+}
+
+// 返回响应
+func reply(w http.ResponseWriter, status, errCode int, errMsg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errcode": errCode,
+		"errmsg":  errMsg,
+	})
+}
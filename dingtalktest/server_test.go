@@ -0,0 +1,145 @@
+package dingtalktest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shockerli/dingtalk"
+	"github.com/shockerli/dingtalk/dingtalktest"
+)
+
+func TestServer(t *testing.T) {
+	srv := dingtalktest.NewServer("SECtest")
+	defer srv.Close()
+
+	robot := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SetSecret(srv.Secret)
+	if err := robot.SendText("TEST: Text", robot.AtAll()); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+
+	messages := srv.Messages()
+	if len(messages) != 1 || messages[0].MsgType != "text" {
+		t.Fatalf("Messages() = %v", messages)
+	}
+	var msg struct {
+		Text struct {
+			Content string `json:"content"`
+		} `json:"text"`
+		At struct {
+			IsAtAll bool `json:"isAtAll"`
+		} `json:"at"`
+	}
+	if err := messages[0].Decode(&msg); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if msg.Text.Content != "TEST: Text" || !msg.At.IsAtAll {
+		t.Errorf("Decode() = %+v", msg)
+	}
+}
+
+func TestServer_Verify(t *testing.T) {
+	srv := dingtalktest.NewServer("SECtest")
+	defer srv.Close()
+
+	err := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SetSecret("SECwrong").SendText("TEST: Text")
+	if !dingtalk.IsSignatureInvalid(err) {
+		t.Errorf("SendText() with wrong secret error = %v, want signature invalid", err)
+	}
+
+	err = dingtalk.NewRobotCustom().SetWebhook(srv.URL + "/robot/send?access_token=wrong").SetSecret(srv.Secret).SendText("TEST: Text")
+	if !dingtalk.IsTokenInvalid(err) {
+		t.Errorf("SendText() with wrong token error = %v, want token invalid", err)
+	}
+
+	if n := len(srv.Messages()); n != 0 {
+		t.Errorf("len(Messages()) = %d, want 0", n)
+	}
+}
+
+func TestServer_Respond(t *testing.T) {
+	srv := dingtalktest.NewServer("")
+	defer srv.Close()
+
+	srv.Respond(
+		dingtalktest.Response{StatusCode: http.StatusBadGateway},
+		dingtalktest.Response{ErrCode: 310000, ErrMsg: "keywords not in content"},
+	)
+	robot := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook())
+
+	var re *dingtalk.RobotError
+	if err := robot.SendText("TEST: Text"); !errors.As(err, &re) || re.StatusCode != http.StatusBadGateway {
+		t.Errorf("SendText() error = %v, want HTTP 502", err)
+	}
+	if err := robot.SendText("TEST: Text"); !dingtalk.IsKeywordMismatch(err) {
+		t.Errorf("SendText() error = %v, want keyword mismatch", err)
+	}
+	if err := robot.SendText("TEST: Text"); err != nil {
+		t.Errorf("SendText() error = %v", err)
+	}
+}
+
+func TestServer_Respond_Verify(t *testing.T) {
+	srv := dingtalktest.NewServer("SECtest")
+	defer srv.Close()
+
+	srv.Respond(dingtalktest.Response{StatusCode: http.StatusBadGateway})
+
+	// 签名错误的请求不使用预设的响应
+	err := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SetSecret("SECwrong").SendText("TEST: Text")
+	if !dingtalk.IsSignatureInvalid(err) {
+		t.Errorf("SendText() with wrong secret error = %v, want signature invalid", err)
+	}
+
+	policy := dingtalk.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	robot := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SetSecret(srv.Secret).SetRetryPolicy(policy)
+	if err = robot.SendText("TEST: Retry"); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+
+	// 使用预设响应的请求同样经过校验并记录
+	attempts := srv.Attempts()
+	if len(attempts) != 2 {
+		t.Fatalf("len(Attempts()) = %d, want 2", len(attempts))
+	}
+	for _, a := range attempts {
+		var msg dingtalk.Message
+		if err = a.Decode(&msg); err != nil || msg.Text.Content != "TEST: Retry" || a.Query.Get("sign") == "" {
+			t.Errorf("attempt = %s %v, want signed TEST: Retry", a.Body, a.Query)
+		}
+	}
+	if n := len(srv.Messages()); n != 1 {
+		t.Errorf("len(Messages()) = %d, want 1", n)
+	}
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	srv := dingtalktest.NewServer("")
+	defer srv.Close()
+
+	srv.SetRateLimit(1, time.Minute)
+	robot := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook())
+	if err := robot.SendText("TEST: Text"); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+	if err := robot.SendText("TEST: Text"); !dingtalk.IsRateLimited(err) {
+		t.Errorf("SendText() error = %v, want rate limited", err)
+	}
+}
+
+func TestServer_SetDelay(t *testing.T) {
+	srv := dingtalktest.NewServer("")
+	defer srv.Close()
+
+	srv.SetDelay(200 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SendTextContext(ctx, "TEST: Text")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SendTextContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
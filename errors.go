@@ -0,0 +1,137 @@
+package dingtalk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// 常见的群机器人错误
+var (
+	ErrRateLimited      = errors.New("群机器人发送频率超限")
+	ErrKeywordMismatch  = errors.New("群机器人消息未包含自定义关键词")
+	ErrSignatureInvalid = errors.New("群机器人签名校验失败")
+	ErrIPNotAllowed     = errors.New("群机器人请求IP不在白名单")
+	ErrTokenInvalid     = errors.New("群机器人access_token无效")
+)
+
+// 群机器人错误码
+//
+// 官方文档: https://open.dingtalk.com/document/orgapp/custom-robots-send-group-messages
+const (
+	errCodeSecurity      = 310000 // 安全设置校验失败，具体原因见errmsg
+	errCodeSendTooFast   = 130101 // 发送速度太快
+	errCodeRateLimited   = 410100 // 发送速度太快而限流
+	errCodeTokenNotExist = 300001 // token不存在
+	errCodeTokenInvalid  = 300005 // token不存在
+	errCodeTokenMissing  = 400101 // access_token不存在
+	errCodeRobotStopped  = 400102 // 机器人已停用
+	errCodeRobotNotExist = 400106 // 机器人不存在
+)
+
+// RobotError 群机器人接口返回的错误
+//
+// 可通过 errors.Is 与 ErrRateLimited 等错误比较，或使用 IsRateLimited 等函数判断
+//
+// 示例:
+//
+//	var re *dingtalk.RobotError
+//	if errors.As(err, &re) {
+//		log.Println(re.ErrCode, re.ErrMsg)
+//	}
+type RobotError struct {
+	ErrCode    int    // 错误码
+	ErrMsg     string // 错误信息
+	StatusCode int    // HTTP状态码
+	RequestID  string // 请求ID
+}
+
+func (e *RobotError) Error() string {
+	msg := fmt.Sprintf("群机器人消息发送失败: [%d] %v", e.ErrCode, e.ErrMsg)
+	if e.StatusCode != http.StatusOK {
+		msg += fmt.Sprintf(" (HTTP %d)", e.StatusCode)
+	}
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request_id: %s)", e.RequestID)
+	}
+	return msg
+}
+
+// Is 支持 errors.Is 与常见错误比较
+func (e *RobotError) Is(target error) bool {
+	msg := strings.ToLower(e.ErrMsg)
+	switch target {
+	case ErrRateLimited:
+		return e.ErrCode == errCodeSendTooFast || e.ErrCode == errCodeRateLimited ||
+			e.StatusCode == http.StatusTooManyRequests || strings.Contains(msg, "send too fast")
+	case ErrKeywordMismatch:
+		return e.ErrCode == errCodeSecurity && strings.Contains(msg, "keywords")
+	case ErrSignatureInvalid:
+		return e.ErrCode == errCodeSecurity && (strings.Contains(msg, "sign") || strings.Contains(msg, "timestamp"))
+	case ErrIPNotAllowed:
+		return e.ErrCode == errCodeSecurity && strings.Contains(msg, "whitelist")
+	case ErrTokenInvalid:
+		switch e.ErrCode {
+		case errCodeTokenNotExist, errCodeTokenInvalid, errCodeTokenMissing, errCodeRobotStopped, errCodeRobotNotExist:
+			return true
+		}
+	}
+	return false
+}
+
+// IsRateLimited 是否为发送频率超限
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// IsKeywordMismatch 是否为消息未包含自定义关键词
+func IsKeywordMismatch(err error) bool {
+	return errors.Is(err, ErrKeywordMismatch)
+}
+
+// IsSignatureInvalid 是否为签名校验失败
+func IsSignatureInvalid(err error) bool {
+	return errors.Is(err, ErrSignatureInvalid)
+}
+
+// IsIPNotAllowed 是否为请求IP不在白名单
+func IsIPNotAllowed(err error) bool {
+	return errors.Is(err, ErrIPNotAllowed)
+}
+
+// IsTokenInvalid 是否为access_token无效
+func IsTokenInvalid(err error) bool {
+	return errors.Is(err, ErrTokenInvalid)
+}
+
+// 解析接口响应
+func parseResponse(resp *http.Response, data []byte) error {
+	var response struct {
+		ErrCode   int    `json:"errcode"`
+		ErrMsg    string `json:"errmsg"`
+		RequestID string `json:"request_id"`
+	}
+	err := json.Unmarshal(data, &response)
+	if err != nil && resp.StatusCode == http.StatusOK {
+		return err
+	}
+	if response.ErrCode == 0 && resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	re := &RobotError{
+		ErrCode:    response.ErrCode,
+		ErrMsg:     response.ErrMsg,
+		StatusCode: resp.StatusCode,
+		RequestID:  response.RequestID,
+	}
+	if re.ErrMsg == "" {
+		re.ErrMsg = http.StatusText(resp.StatusCode)
+	}
+	if re.RequestID == "" {
+		re.RequestID = resp.Header.Get("X-Acs-Request-Id")
+	}
+	return re
+}
@@ -0,0 +1,52 @@
+package dingtalk_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shockerli/dingtalk"
+)
+
+func TestRobotError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		is     func(error) bool
+		code   int
+	}{
+		{"RateLimited", http.StatusOK, `{"errcode":410100,"errmsg":"send too fast, exceed 20 times per minute"}`, dingtalk.IsRateLimited, 410100},
+		{"KeywordMismatch", http.StatusOK, `{"errcode":310000,"errmsg":"keywords not in content"}`, dingtalk.IsKeywordMismatch, 310000},
+		{"SignatureInvalid", http.StatusOK, `{"errcode":310000,"errmsg":"sign not match"}`, dingtalk.IsSignatureInvalid, 310000},
+		{"IPNotAllowed", http.StatusOK, `{"errcode":310000,"errmsg":"ip X.X.X.X not in whitelist"}`, dingtalk.IsIPNotAllowed, 310000},
+		{"TokenInvalid", http.StatusOK, `{"errcode":300001,"errmsg":"token is not exist"}`, dingtalk.IsTokenInvalid, 300001},
+		{"ServerError", http.StatusBadGateway, `bad gateway`, func(error) bool { return true }, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			err := dingtalk.NewRobotCustom().
+				SetWebhook(srv.URL + "/robot/send?access_token=test").
+				SendText("TEST: RobotError")
+
+			var re *dingtalk.RobotError
+			if !errors.As(err, &re) {
+				t.Fatalf("SendText() error = %v, want *RobotError", err)
+			}
+			if re.ErrCode != tt.code || re.StatusCode != tt.status {
+				t.Errorf("RobotError = %+v, want errcode %d, status %d", re, tt.code, tt.status)
+			}
+			if !tt.is(err) {
+				t.Errorf("%s predicate = false, error = %v", tt.name, err)
+			}
+		})
+	}
+}
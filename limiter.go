@@ -0,0 +1,130 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 群机器人默认发送频率限制: 每分钟最多20条
+const (
+	DefaultRateLimit       = 20
+	DefaultRateLimitWindow = time.Minute
+)
+
+// ErrQuotaExceeded 本地限流额度已用尽
+//
+// errors.Is(ErrQuotaExceeded, ErrRateLimited) 为 true
+var ErrQuotaExceeded = fmt.Errorf("群机器人本地限流: %w", ErrRateLimited)
+
+// 消息因限流被丢弃
+var errDropped = errors.New("群机器人本地限流: 消息已丢弃")
+
+// 等待额度时ctx结束，errors.Is可判断原因(context.DeadlineExceeded等)
+type limitWaitError struct {
+	err error
+}
+
+func (e *limitWaitError) Error() string {
+	return "群机器人本地限流: 等待额度时" + e.err.Error()
+}
+
+func (e *limitWaitError) Unwrap() error {
+	return e.err
+}
+
+// 是否为本地限流导致未发送
+func isLocalLimit(err error) bool {
+	var lw *limitWaitError
+	return errors.Is(err, ErrQuotaExceeded) || errors.Is(err, errDropped) || errors.As(err, &lw)
+}
+
+// RateLimitMode 额度用尽时的处理方式
+type RateLimitMode int
+
+// 额度用尽时的处理方式
+const (
+	RateLimitBlock    RateLimitMode = iota // 阻塞等待，直到有可用额度或ctx结束
+	RateLimitFailFast                      // 立即返回 ErrQuotaExceeded
+	RateLimitDrop                          // 丢弃消息，不返回错误(日志、指标记为未发送)
+)
+
+// RateLimiter 基于滑动窗口的发送频率限制
+//
+// 同一Webhook的多个RobotCustom实例应共享同一个RateLimiter
+type RateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	mode   RateLimitMode
+	sent   []time.Time // 窗口内的发送时间，按时间升序
+}
+
+// NewRateLimiter 实例化，窗口window内最多发送limit条消息
+//
+// 示例:
+// 	limiter := dingtalk.NewRateLimiter(dingtalk.DefaultRateLimit, dingtalk.DefaultRateLimitWindow, dingtalk.RateLimitBlock)
+func NewRateLimiter(limit int, window time.Duration, mode RateLimitMode) *RateLimiter {
+	if limit <= 0 {
+		limit = DefaultRateLimit
+	}
+	if window <= 0 {
+		window = DefaultRateLimitWindow
+	}
+	return &RateLimiter{
+		limit:  limit,
+		window: window,
+		mode:   mode,
+	}
+}
+
+// Remaining 当前窗口内剩余可发送数量
+func (l *RateLimiter) Remaining() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.expire(time.Now())
+	return l.limit - len(l.sent)
+}
+
+// 获取一个发送额度
+func (l *RateLimiter) acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.expire(now)
+		if len(l.sent) < l.limit {
+			l.sent = append(l.sent, now)
+			l.mu.Unlock()
+			return nil
+		}
+		wait := l.sent[0].Add(l.window).Sub(now)
+		l.mu.Unlock()
+
+		switch l.mode {
+		case RateLimitFailFast:
+			return ErrQuotaExceeded
+		case RateLimitDrop:
+			return errDropped
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &limitWaitError{err: ctx.Err()}
+		case <-timer.C:
+		}
+	}
+}
+
+// 移除窗口外的发送记录
+func (l *RateLimiter) expire(now time.Time) {
+	i := 0
+	for i < len(l.sent) && now.Sub(l.sent[i]) >= l.window {
+		i++
+	}
+	l.sent = l.sent[i:]
+}
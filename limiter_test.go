@@ -0,0 +1,56 @@
+package dingtalk_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shockerli/dingtalk"
+)
+
+func TestRateLimiter(t *testing.T) {
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook("https://oapi.dingtalk.com/robot/send?access_token=test").
+		SetTransport(okTransport()).
+		SetRateLimit(2, time.Minute, dingtalk.RateLimitFailFast)
+
+	for i := 0; i < 2; i++ {
+		if err := rc.SendText("TEST: RateLimit"); err != nil {
+			t.Fatalf("SendText() error = %v", err)
+		}
+	}
+	if got := rc.RateLimitRemaining(); got != 0 {
+		t.Errorf("RateLimitRemaining() = %d, want 0", got)
+	}
+
+	err := rc.SendText("TEST: RateLimit")
+	if !errors.Is(err, dingtalk.ErrQuotaExceeded) || !dingtalk.IsRateLimited(err) {
+		t.Errorf("SendText() error = %v, want %v", err, dingtalk.ErrQuotaExceeded)
+	}
+
+	// 丢弃模式
+	rc.SetRateLimit(1, time.Minute, dingtalk.RateLimitDrop)
+	_ = rc.SendText("TEST: RateLimit")
+	if err := rc.SendText("TEST: RateLimit"); err != nil {
+		t.Errorf("SendText() with RateLimitDrop error = %v", err)
+	}
+
+	// 阻塞模式
+	rc.SetRateLimit(1, 50*time.Millisecond, dingtalk.RateLimitBlock)
+	_ = rc.SendText("TEST: RateLimit")
+	start := time.Now()
+	if err := rc.SendText("TEST: RateLimit"); err != nil {
+		t.Errorf("SendText() with RateLimitBlock error = %v", err)
+	}
+	if time.Since(start) < 40*time.Millisecond {
+		t.Errorf("SendText() with RateLimitBlock did not wait")
+	}
+
+	_ = rc.SendText("TEST: RateLimit")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := rc.SendTextContext(ctx, "TEST: RateLimit"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SendTextContext() with RateLimitBlock error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,51 @@
+package dingtalk
+
+import (
+	"context"
+)
+
+// LogLevel 日志级别
+type LogLevel int
+
+// 日志级别
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "DEBUG"
+	case LogInfo:
+		return "INFO"
+	case LogWarn:
+		return "WARN"
+	case LogError:
+		return "ERROR"
+	}
+	return "UNKNOWN"
+}
+
+// Logger 日志接口，kv为交替出现的键值对
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, msg string, kv ...interface{})
+}
+
+// LoggerFunc 函数形式的Logger
+type LoggerFunc func(ctx context.Context, level LogLevel, msg string, kv ...interface{})
+
+// Log 记录日志
+func (f LoggerFunc) Log(ctx context.Context, level LogLevel, msg string, kv ...interface{}) {
+	f(ctx, level, msg, kv...)
+}
+
+// 用于日志的错误信息，已脱敏
+func logError(err error) interface{} {
+	if err == nil {
+		return nil
+	}
+	return redactURL(err.Error())
+}
@@ -0,0 +1,41 @@
+//go:build go1.21
+// +build go1.21
+
+package dingtalk
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogLogger 基于log/slog的Logger
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger 实例化，l为nil时使用slog.Default()
+//
+// 示例:
+// 	robot.SetLogger(dingtalk.NewSlogLogger(slog.Default()))
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &SlogLogger{logger: l}
+}
+
+// Log 记录日志
+func (sl *SlogLogger) Log(ctx context.Context, level LogLevel, msg string, kv ...interface{}) {
+	var lvl slog.Level
+	switch level {
+	case LogDebug:
+		lvl = slog.LevelDebug
+	case LogInfo:
+		lvl = slog.LevelInfo
+	case LogWarn:
+		lvl = slog.LevelWarn
+	default:
+		lvl = slog.LevelError
+	}
+	sl.logger.Log(ctx, lvl, msg, kv...)
+}
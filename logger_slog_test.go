@@ -0,0 +1,24 @@
+//go:build go1.21
+// +build go1.21
+
+package dingtalk_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/shockerli/dingtalk"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := dingtalk.NewSlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	l.Log(context.Background(), dingtalk.LogWarn, "dingtalk: retrying", "attempt", 1)
+	if out := buf.String(); !strings.Contains(out, "level=WARN") || !strings.Contains(out, "attempt=1") {
+		t.Errorf("slog output = %q", out)
+	}
+}
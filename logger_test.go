@@ -0,0 +1,101 @@
+package dingtalk_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shockerli/dingtalk"
+)
+
+// 记录日志
+type logRecorder struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (lr *logRecorder) Log(ctx context.Context, level dingtalk.LogLevel, msg string, kv ...interface{}) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.entries = append(lr.entries, fmt.Sprint(level, " ", msg, " ", kv))
+}
+
+func (lr *logRecorder) String() string {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	return strings.Join(lr.entries, "\n")
+}
+
+func TestRobotCustom_SetLogger(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer srv.Close()
+
+	lr := &logRecorder{}
+	policy := dingtalk.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook(srv.URL + "/robot/send?access_token=secret_token").
+		SetSecret("SECtest").
+		SetRetryPolicy(policy).
+		SetLogger(lr)
+
+	if err := rc.SendText("TEST: Logger"); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+
+	out := lr.String()
+	for _, want := range []string{"DEBUG dingtalk: request", "WARN dingtalk: retrying", "INFO dingtalk: message sent", "access_token=REDACTED", "sign=REDACTED"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log does not contain %q\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "secret_token") {
+		t.Errorf("log leaks credentials\n%s", out)
+	}
+}
+
+func TestRobotCustom_SetLogger_RetryContext(t *testing.T) {
+	type traceKey struct{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	// 重试日志使用发送时的上下文
+	var mu sync.Mutex
+	var traces []interface{}
+	policy := dingtalk.DefaultRetryPolicy()
+	policy.MaxAttempts = 2
+	policy.InitialBackoff = time.Millisecond
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook(srv.URL + "/robot/send?access_token=test").
+		SetRetryPolicy(policy).
+		SetLogger(dingtalk.LoggerFunc(func(ctx context.Context, level dingtalk.LogLevel, msg string, kv ...interface{}) {
+			if msg == "dingtalk: retrying" {
+				mu.Lock()
+				traces = append(traces, ctx.Value(traceKey{}))
+				mu.Unlock()
+			}
+		}))
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "trace-1")
+	if err := rc.Send(ctx, dingtalk.NewTextMessage("TEST: Logger")); err == nil {
+		t.Fatal("Send() error = nil, want error")
+	}
+	if len(traces) != 1 || traces[0] != "trace-1" {
+		t.Errorf("retry log contexts = %v, want [trace-1]", traces)
+	}
+}
@@ -0,0 +1,254 @@
+package dingtalk
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MarkdownBuilder Markdown消息内容构造器，仅生成群机器人支持的Markdown语法
+//
+// 行内内容(Text/Bold/Italic/Link/Font/Mention)依次拼接为一行，
+// 行与行、块与块之间以"\n\n"分隔，以满足群机器人的换行规则
+//
+// 示例:
+// 	md := dingtalk.NewMarkdown().
+// 		Heading(3, "服务告警").
+// 		Text("服务 ").Bold("order-api").Text(" 响应超时").Break().
+// 		Quote("P99: 3.2s").
+// 		List("机房: 杭州", "实例: 10.0.0.1").
+// 		Mention("19900001111")
+// 	err := robot.SendMarkdown("服务告警", md.String(), robot.AtMobiles(md.Mentions()...))
+type MarkdownBuilder struct {
+	blocks   []string
+	line     strings.Builder
+	mentions []string
+	escape   bool // 转义传入的文本
+}
+
+// NewMarkdown 实例化Markdown构造器
+func NewMarkdown() *MarkdownBuilder {
+	return &MarkdownBuilder{}
+}
+
+// Escape 设置是否转义传入的文本，用于嵌入用户输入、日志等不可信内容
+//
+// 开启后，除 Raw 及 Mention 外，传入的文本均经过 EscapeMarkdown 转义，链接及图片URL中的空格和括号被编码
+//
+// 示例:
+// 	md := dingtalk.NewMarkdown().Escape(true).
+// 		Heading(3, "错误日志").
+// 		Quote(logLine).
+// 		Raw("**请尽快处理**")
+func (b *MarkdownBuilder) Escape(enabled bool) *MarkdownBuilder {
+	b.escape = enabled
+	return b
+}
+
+// Raw 原样添加的Markdown内容，不受 Escape 影响
+func (b *MarkdownBuilder) Raw(s string) *MarkdownBuilder {
+	b.line.WriteString(s)
+	return b
+}
+
+// Heading 标题，level取值1~6
+func (b *MarkdownBuilder) Heading(level int, text string) *MarkdownBuilder {
+	if level < 1 {
+		level = 1
+	}
+	if level > 6 {
+		level = 6
+	}
+	return b.block(strings.Repeat("#", level) + " " + b.text(text))
+}
+
+// Text 普通文本
+func (b *MarkdownBuilder) Text(s string) *MarkdownBuilder {
+	return b.Raw(b.text(s))
+}
+
+// Bold 加粗
+func (b *MarkdownBuilder) Bold(s string) *MarkdownBuilder {
+	return b.Raw("**" + b.text(s) + "**")
+}
+
+// Italic 斜体
+func (b *MarkdownBuilder) Italic(s string) *MarkdownBuilder {
+	return b.Raw("*" + b.text(s) + "*")
+}
+
+// Link 链接
+func (b *MarkdownBuilder) Link(text, url string) *MarkdownBuilder {
+	return b.Raw("[" + b.text(text) + "](" + b.url(url) + ")")
+}
+
+// Font 带颜色的文字，color为颜色值，例: #FF0000
+func (b *MarkdownBuilder) Font(s, color string) *MarkdownBuilder {
+	return b.Raw(fmt.Sprintf("<font color=%s>%s</font>", b.color(color), b.text(s)))
+}
+
+// Mention @指定手机号的人，需同时通过 AtMobiles(b.Mentions()...) 设置才会提醒
+func (b *MarkdownBuilder) Mention(mobile string) *MarkdownBuilder {
+	b.mentions = appendUnique(b.mentions, mobile)
+	if s := b.line.String(); s != "" && !strings.HasSuffix(s, " ") {
+		b.line.WriteString(" ")
+	}
+	return b.Raw("@" + mobile)
+}
+
+// Break 换行
+func (b *MarkdownBuilder) Break() *MarkdownBuilder {
+	b.flush()
+	return b
+}
+
+// Quote 引用，多行内容逐行引用
+func (b *MarkdownBuilder) Quote(s string) *MarkdownBuilder {
+	return b.block("> " + strings.Replace(b.text(s), "\n", "\n\n> ", -1))
+}
+
+// List 无序列表
+func (b *MarkdownBuilder) List(items ...string) *MarkdownBuilder {
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, "- "+b.text(item))
+	}
+	return b.block(strings.Join(lines, "\n"))
+}
+
+// OrderedList 有序列表
+func (b *MarkdownBuilder) OrderedList(items ...string) *MarkdownBuilder {
+	lines := make([]string, 0, len(items))
+	for i, item := range items {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, b.text(item)))
+	}
+	return b.block(strings.Join(lines, "\n"))
+}
+
+// Image 图片
+func (b *MarkdownBuilder) Image(alt, url string) *MarkdownBuilder {
+	return b.block("![" + b.text(alt) + "](" + b.url(url) + ")")
+}
+
+// HR 分割线
+func (b *MarkdownBuilder) HR() *MarkdownBuilder {
+	return b.block("---")
+}
+
+// Mentions 通过 Mention 添加的手机号
+func (b *MarkdownBuilder) Mentions() []string {
+	return append([]string(nil), b.mentions...)
+}
+
+// String 生成Markdown内容
+func (b *MarkdownBuilder) String() string {
+	blocks := b.blocks
+	if b.line.Len() > 0 {
+		blocks = append(blocks[:len(blocks):len(blocks)], b.line.String())
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// Message 构造Markdown消息，并@通过 Mention 添加的手机号
+func (b *MarkdownBuilder) Message(title string) *Message {
+	msg := NewMarkdownMessage(title, b.String())
+	if len(b.mentions) > 0 {
+		msg.At = &RobotAt{AtMobiles: b.Mentions()}
+	}
+	return msg
+}
+
+// 按需转义文本
+func (b *MarkdownBuilder) text(s string) string {
+	if b.escape {
+		return EscapeMarkdown(s)
+	}
+	return s
+}
+
+// 按需编码URL中会截断链接语法的字符
+func (b *MarkdownBuilder) url(s string) string {
+	if b.escape {
+		return urlEscaper.Replace(s)
+	}
+	return s
+}
+
+// 按需过滤颜色值中的非法字符
+func (b *MarkdownBuilder) color(s string) string {
+	if !b.escape {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '#' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// 添加块级内容，先结束当前行
+func (b *MarkdownBuilder) block(s string) *MarkdownBuilder {
+	b.flush()
+	b.blocks = append(b.blocks, s)
+	return b
+}
+
+// 结束当前行
+func (b *MarkdownBuilder) flush() {
+	if b.line.Len() > 0 {
+		b.blocks = append(b.blocks, b.line.String())
+		b.line.Reset()
+	}
+}
+
+// 链接语法中需编码的URL字符
+var urlEscaper = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E")
+
+// EscapeMarkdown 转义Markdown文本，使其按原样展示
+//
+// * Markdown语法字符以反斜杠转义，行首的列表、标题、引用标记一并转义
+// * <、>、& 转换为HTML实体，避免注入<font>等标签
+// * @后插入零宽空格，避免误@他人
+//
+// 示例:
+// 	robot.SendMarkdown("错误日志", "> "+dingtalk.EscapeMarkdown(logLine))
+func EscapeMarkdown(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	lineStart := true
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '&':
+			b.WriteString("&amp;")
+		case c == '<':
+			b.WriteString("&lt;")
+		case c == '>':
+			b.WriteString("&gt;")
+		case c == '@':
+			b.WriteString("@\u200b")
+		case strings.IndexByte("\\`*_[]()#!|~", c) >= 0:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case lineStart && (c == '-' || c == '+'):
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case lineStart && c >= '0' && c <= '9':
+			// 行首的"1."会被识别为有序列表
+			j := i
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			b.WriteString(s[i:j])
+			if j < len(s) && s[j] == '.' {
+				b.WriteString("\\.")
+				j++
+			}
+			i = j - 1
+		default:
+			b.WriteByte(c)
+		}
+		lineStart = c == '\n' || (lineStart && (c == ' ' || c == '\t'))
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package dingtalk_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/shockerli/dingtalk"
+	"github.com/shockerli/dingtalk/dingtalktest"
+)
+
+func TestMarkdownBuilder(t *testing.T) {
+	md := dingtalk.NewMarkdown().
+		Heading(3, "服务告警").
+		Text("服务 ").Bold("order-api").Text(" 响应").Italic("超时").Break().
+		Font("P99: 3.2s", "#FF0000").
+		Quote("第一行\n第二行").
+		List("机房: 杭州", "实例: 10.0.0.1").
+		OrderedList("重启", "扩容").
+		HR().
+		Image("趋势", "https://github.com/shockerli.png").
+		Link("详情", "https://github.com/shockerli").
+		Mention("19900001111").Mention("19900001111")
+
+	want := "### 服务告警\n\n" +
+		"服务 **order-api** 响应*超时*\n\n" +
+		"<font color=#FF0000>P99: 3.2s</font>\n\n" +
+		"> 第一行\n\n> 第二行\n\n" +
+		"- 机房: 杭州\n- 实例: 10.0.0.1\n\n" +
+		"1. 重启\n2. 扩容\n\n" +
+		"---\n\n" +
+		"![趋势](https://github.com/shockerli.png)\n\n" +
+		"[详情](https://github.com/shockerli) @19900001111 @19900001111"
+	if got := md.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got := md.Mentions(); !reflect.DeepEqual(got, []string{"19900001111"}) {
+		t.Errorf("Mentions() = %v", got)
+	}
+	if got := dingtalk.NewMarkdown().Heading(9, "title").String(); got != "###### title" {
+		t.Errorf("Heading(9) = %q", got)
+	}
+}
+
+func TestMarkdownBuilder_Message(t *testing.T) {
+	srv := dingtalktest.NewServer("SECtest")
+	defer srv.Close()
+
+	md := dingtalk.NewMarkdown().Heading(2, "TEST: Builder").Text("hello").Mention("19900001111")
+	rc := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SetSecret(srv.Secret)
+	if err := rc.Send(context.Background(), md.Message("TEST: Builder")); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	var msg dingtalk.Message
+	if err := srv.Messages()[0].Decode(&msg); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if msg.Markdown.Text != md.String() || msg.At == nil || !reflect.DeepEqual(msg.At.AtMobiles, []string{"19900001111"}) {
+		t.Errorf("sent = %+v %+v", msg.Markdown, msg.At)
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain text", "plain text"},
+		{"**bold** _x_ `code`", `\*\*bold\*\* \_x\_ \` + "`code\\`"},
+		{"[fake](https://evil.com)", `\[fake\]\(https://evil.com\)`},
+		{"# title\n- item\n+ item\n  1. first\nv1.2", "\\# title\n\\- item\n\\+ item\n  1\\. first\nv1.2"},
+		{"a-b 10.5", "a-b 10.5"},
+		{"<font color=red>x</font> & > quote", "&lt;font color=red&gt;x&lt;/font&gt; &amp; &gt; quote"},
+		{"call @19900001111", "call @\u200b19900001111"},
+		{`C:\path|中文!`, `C:\\path\|中文\!`},
+	}
+	for _, tt := range tests {
+		if got := dingtalk.EscapeMarkdown(tt.in); got != tt.want {
+			t.Errorf("EscapeMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdownBuilder_Escape(t *testing.T) {
+	md := dingtalk.NewMarkdown().Escape(true).
+		Heading(3, "#1 错误").
+		Text("user: ").Bold("*admin*").Break().
+		Font("<b>", "#FF0000\"><script>").Break().
+		Link("[点击](x)", "https://example.com/a b(1)").
+		Mention("19900001111").Break().
+		Raw("**raw**")
+
+	want := "### \\#1 错误\n\n" +
+		"user: **\\*admin\\***\n\n" +
+		"<font color=#FF0000script>&lt;b&gt;</font>\n\n" +
+		"[\\[点击\\]\\(x\\)](https://example.com/a%20b%281%29) @19900001111\n\n" +
+		"**raw**"
+	if got := md.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,109 @@
+package dingtalk
+
+import (
+	"strings"
+)
+
+// MentionMarker @人标记的占位符，开启自动补全@人标记后替换为被@人的标记
+//
+// 示例:
+// 	robot.SetMentionInjection(true)
+// 	robot.SendMarkdown("服务告警", "### 服务告警\n\n"+dingtalk.MentionMarker+" 请尽快处理", robot.AtMobiles("19900001111"))
+const MentionMarker = "{@mentions}"
+
+// 补全消息内容中缺少的@人标记，有改动时返回副本
+func injectMentions(msg *Message) *Message {
+	var content string
+	switch {
+	case msg.MsgType == MsgTypeText && msg.Text != nil:
+		content = msg.Text.Content
+	case msg.MsgType == MsgTypeMarkdown && msg.Markdown != nil:
+		content = msg.Markdown.Text
+	default:
+		return msg
+	}
+
+	var tokens []string
+	if msg.At != nil {
+		for _, id := range append(append([]string(nil), msg.At.AtMobiles...), msg.At.AtUserIDs...) {
+			if token := "@" + id; id != "" && !containsMention(content, token) {
+				tokens = appendUnique(tokens, token)
+			}
+		}
+	}
+	if len(tokens) == 0 && !strings.Contains(content, MentionMarker) {
+		return msg
+	}
+
+	mentions := strings.Join(tokens, " ")
+	switch {
+	case strings.Contains(content, MentionMarker):
+		content = strings.Replace(content, MentionMarker, mentions, -1)
+	case strings.TrimSpace(content) == "":
+		content = mentions
+	case msg.MsgType == MsgTypeMarkdown:
+		content = strings.TrimRight(content, "\n") + "\n\n" + mentions
+	default:
+		content = strings.TrimRight(content, "\n") + "\n" + mentions
+	}
+
+	c := msg.clone()
+	if c.MsgType == MsgTypeMarkdown {
+		c.Markdown.Text = content
+	} else {
+		c.Text.Content = content
+	}
+	return c
+}
+
+// 转义Markdown文本，保留被@人的@标记
+func escapeMarkdownMentions(s string, at *RobotAt) string {
+	var ids []string
+	if at != nil {
+		ids = append(append(ids, at.AtMobiles...), at.AtUserIDs...)
+	}
+
+	var b strings.Builder
+	for {
+		// 最先出现的完整@人标记
+		start, token := -1, ""
+		for _, id := range ids {
+			t := "@" + id
+			if i := indexMention(s, t); id != "" && i >= 0 && (start < 0 || i < start) {
+				start, token = i, t
+			}
+		}
+		if start < 0 {
+			b.WriteString(EscapeMarkdown(s))
+			return b.String()
+		}
+		b.WriteString(EscapeMarkdown(s[:start]))
+		b.WriteString(token)
+		s = s[start+len(token):]
+	}
+}
+
+// 内容中是否已包含完整的@人标记
+func containsMention(content, token string) bool {
+	return indexMention(content, token) >= 0
+}
+
+// 完整@人标记的位置，不存在时返回-1
+func indexMention(content, token string) int {
+	for i := 0; ; {
+		j := strings.Index(content[i:], token)
+		if j < 0 {
+			return -1
+		}
+		end := i + j + len(token)
+		if end == len(content) || !isMentionChar(content[end]) {
+			return i + j
+		}
+		i = end
+	}
+}
+
+// 手机号、用户ID中的字符
+func isMentionChar(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '-'
+}
@@ -0,0 +1,68 @@
+package dingtalk_test
+
+import (
+	"testing"
+
+	"github.com/shockerli/dingtalk"
+	"github.com/shockerli/dingtalk/dingtalktest"
+)
+
+func TestRobotCustom_SetMentionInjection(t *testing.T) {
+	srv := dingtalktest.NewServer("SECtest")
+	defer srv.Close()
+
+	rc := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SetSecret(srv.Secret).SetMentionInjection(true)
+	tests := []struct {
+		name string
+		send func() error
+		want string
+	}{
+		{
+			"Markdown",
+			func() error {
+				return rc.SendMarkdown("TEST: Mention", "### 告警 @19900001111\n", rc.AtMobiles("19900001111", "1990000111"), rc.AtUserIDs("manager1234"))
+			},
+			"### 告警 @19900001111\n\n@1990000111 @manager1234",
+		},
+		{
+			"Marker",
+			func() error {
+				return rc.SendMarkdown("TEST: Mention", "### 告警\n\n"+dingtalk.MentionMarker+" 请处理", rc.AtUserIDs("manager1234"))
+			},
+			"### 告警\n\n@manager1234 请处理",
+		},
+		{
+			"Text",
+			func() error { return rc.SendText("TEST: Mention", rc.AtMobiles("19900001111")) },
+			"TEST: Mention\n@19900001111",
+		},
+		{
+			"NoMentions",
+			func() error { return rc.SendText("TEST: Mention", rc.AtAll()) },
+			"TEST: Mention",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv.Reset()
+			if err := tt.send(); err != nil {
+				t.Fatalf("send error = %v", err)
+			}
+			msgs := sentMessages(t, srv)
+			if len(msgs) != 1 {
+				t.Fatalf("len(Messages()) = %d, want 1", len(msgs))
+			}
+			got := msgs[0].Text
+			if got == nil {
+				if msgs[0].Markdown.Text != tt.want {
+					t.Errorf("text = %q, want %q", msgs[0].Markdown.Text, tt.want)
+				}
+				return
+			}
+			if got.Content != tt.want {
+				t.Errorf("content = %q, want %q", got.Content, tt.want)
+			}
+		})
+	}
+}
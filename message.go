@@ -0,0 +1,240 @@
+package dingtalk
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 机器人消息类型
+const (
+	MsgTypeText       = "text"
+	MsgTypeLink       = "link"
+	MsgTypeMarkdown   = "markdown"
+	MsgTypeActionCard = "actionCard"
+	MsgTypeFeedCard   = "feedCard"
+)
+
+// 消息内容的最大长度(字节)
+const maxContentBytes = 20000
+
+// Message 机器人消息
+//
+// 可通过 NewTextMessage 等函数构造，Marshal 生成的JSON即为接口请求体
+//
+// 示例:
+// 	msg := dingtalk.NewTextMessage("TEST: Text").With(robot.AtAll())
+// 	payload, err := msg.Marshal()
+// 	err = robot.Send(ctx, msg)
+type Message struct {
+	MsgType    string           `json:"msgtype"` // 消息类型
+	At         *RobotAt         `json:"at,omitempty"`
+	Text       *RobotText       `json:"text,omitempty"`
+	Link       *RobotLink       `json:"link,omitempty"`
+	Markdown   *RobotMarkdown   `json:"markdown,omitempty"`
+	ActionCard *RobotActionCard `json:"actionCard,omitempty"`
+	FeedCard   *RobotFeedCard   `json:"feedCard,omitempty"`
+	outgoing   RobotOutgoing
+	optErrs    []error // 应用配置项产生的错误
+}
+
+// With 应用消息配置项，配置项的错误通过 Err 获取
+//
+// 示例:
+// 	msg := dingtalk.NewMarkdownMessage("TEST: Markdown", markdown).With(robot.AtMobiles("19900001111"))
+func (msg *Message) With(opts ...RobotOption) *Message {
+	for _, opt := range opts {
+		if err := opt(msg); err != nil {
+			msg.optErrs = append(msg.optErrs, err)
+		}
+	}
+	return msg
+}
+
+// Err 应用配置项产生的错误，存在多个时合并为一个
+func (msg *Message) Err() error {
+	switch len(msg.optErrs) {
+	case 0:
+		return nil
+	case 1:
+		return msg.optErrs[0]
+	}
+
+	rest := make([]string, 0, len(msg.optErrs)-1)
+	for _, err := range msg.optErrs[1:] {
+		rest = append(rest, err.Error())
+	}
+	return fmt.Errorf("%w; %s", msg.optErrs[0], strings.Join(rest, "; "))
+}
+
+// Marshal 生成接口请求体
+func (msg *Message) Marshal() ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+// 深拷贝消息，避免中间件修改调用方的消息
+func (msg *Message) clone() *Message {
+	c := *msg
+	c.optErrs = append([]error(nil), msg.optErrs...)
+	if msg.At != nil {
+		at := *msg.At
+		at.AtMobiles = append([]string(nil), msg.At.AtMobiles...)
+		at.AtUserIDs = append([]string(nil), msg.At.AtUserIDs...)
+		c.At = &at
+	}
+	if msg.Text != nil {
+		text := *msg.Text
+		c.Text = &text
+	}
+	if msg.Link != nil {
+		link := *msg.Link
+		c.Link = &link
+	}
+	if msg.Markdown != nil {
+		markdown := *msg.Markdown
+		c.Markdown = &markdown
+	}
+	if msg.ActionCard != nil {
+		card := *msg.ActionCard
+		card.Btns = append([]RobotActionCardBtn(nil), msg.ActionCard.Btns...)
+		c.ActionCard = &card
+	}
+	if msg.FeedCard != nil {
+		feed := *msg.FeedCard
+		feed.Links = append([]RobotFeedCardLink{}, msg.FeedCard.Links...)
+		c.FeedCard = &feed
+	}
+	return &c
+}
+
+// 消息摘要
+func (msg *Message) summary() string {
+	var s string
+	switch {
+	case msg.Text != nil:
+		s = msg.Text.Content
+	case msg.Link != nil:
+		s = msg.Link.Title
+	case msg.Markdown != nil:
+		s = msg.Markdown.Title
+	case msg.ActionCard != nil:
+		s = msg.ActionCard.Title
+	case msg.FeedCard != nil && len(msg.FeedCard.Links) > 0:
+		s = msg.FeedCard.Links[0].Title
+	}
+	return truncateBytes(s, 200)
+}
+
+// NewTextMessage 构造Text消息
+func NewTextMessage(content string) *Message {
+	return &Message{
+		MsgType: MsgTypeText,
+		Text:    &RobotText{Content: content},
+	}
+}
+
+// NewLinkMessage 构造Link消息
+func NewLinkMessage(title, text, msgURL, picURL string) *Message {
+	return &Message{
+		MsgType: MsgTypeLink,
+		Link: &RobotLink{
+			Title:      title,
+			Text:       text,
+			MessageURL: msgURL,
+			PicURL:     picURL,
+		},
+	}
+}
+
+// NewMarkdownMessage 构造Markdown消息
+func NewMarkdownMessage(title, text string) *Message {
+	return &Message{
+		MsgType: MsgTypeMarkdown,
+		Markdown: &RobotMarkdown{
+			Title: title,
+			Text:  text,
+		},
+	}
+}
+
+// NewActionCardMessage 构造ActionCard消息，默认展示头像、按钮横向排列
+func NewActionCardMessage(title, text string) *Message {
+	return &Message{
+		MsgType: MsgTypeActionCard,
+		ActionCard: &RobotActionCard{
+			Title:          title,
+			Text:           text,
+			HideAvatar:     "0", // 默认展示
+			BtnOrientation: "1", // 默认横向排列
+		},
+	}
+}
+
+// NewFeedCardMessage 构造FeedCard消息，通过 FeedCard 选项添加条目
+func NewFeedCardMessage() *Message {
+	return &Message{
+		MsgType: MsgTypeFeedCard,
+		FeedCard: &RobotFeedCard{
+			Links: []RobotFeedCardLink{},
+		},
+	}
+}
+
+// RobotAt 消息@人的设置
+// [NOTICE] 仅针对Text/Link/Markdown类型有效
+type RobotAt struct {
+	AtMobiles []string `json:"atMobiles,omitempty"` // 被@人的手机号
+	AtUserIDs []string `json:"atUserIds,omitempty"` // 被@人的用户ID
+	IsAtAll   bool     `json:"isAtAll,omitempty"`   // 是否@所有人
+}
+
+// RobotText 消息类型: Text
+type RobotText struct {
+	Content string `json:"content"` // 消息内容
+}
+
+// RobotLink 消息类型: Link
+type RobotLink struct {
+	Title      string `json:"title"`            // 消息标题
+	Text       string `json:"text"`             // 消息内容，如果太长只会部分展示
+	MessageURL string `json:"messageUrl"`       // 点击消息跳转的UR
+	PicURL     string `json:"picUrl,omitempty"` // 图片URL
+}
+
+// RobotMarkdown 消息类型: Markdown
+type RobotMarkdown struct {
+	Title string `json:"title"` // 首屏会话透出的展示内容
+	Text  string `json:"text"`  // Markdown格式的消息
+}
+
+// RobotActionCard 消息类型: ActionCard
+// * 整体跳转
+// * 独立跳转
+// [NOTICE]设置singleTitle和singleURL后，btns无效
+type RobotActionCard struct {
+	Title          string               `json:"title"`                    // 首屏会话透出的展示内容
+	Text           string               `json:"text"`                     // Markdown格式的消息
+	SingleTitle    string               `json:"singleTitle,omitempty"`    // 单个按钮的标题
+	SingleURL      string               `json:"singleURL,omitempty"`      // 点击singleTitle按钮触发的URL
+	HideAvatar     string               `json:"hideAvatar,omitempty"`     // 0：显示图片，1：隐藏图片
+	BtnOrientation string               `json:"btnOrientation,omitempty"` // 0：按钮竖直排列，1：按钮横向排列
+	Btns           []RobotActionCardBtn `json:"btns,omitempty"`           // 独立跳转的按钮列表
+}
+
+// RobotActionCardBtn ActionCard独立跳转的按钮
+type RobotActionCardBtn struct {
+	Title     string `json:"title"`     // 按钮标题
+	ActionURL string `json:"actionURL"` // 点击按钮触发的URL
+}
+
+// RobotFeedCard 消息类型: FeedCard
+type RobotFeedCard struct {
+	Links []RobotFeedCardLink `json:"links"`
+}
+
+// RobotFeedCardLink FeedCard的条目
+type RobotFeedCardLink struct {
+	Title      string `json:"title"`
+	MessageURL string `json:"messageURL"` // 点击单条信息到跳转链接
+	PicURL     string `json:"picURL"`     // 单条信息后面图片的URL
+}
@@ -0,0 +1,131 @@
+package dingtalk_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shockerli/dingtalk"
+	"github.com/shockerli/dingtalk/dingtalktest"
+)
+
+func TestMessage_Marshal(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *dingtalk.Message
+		want string
+	}{
+		{
+			"Text",
+			dingtalk.NewTextMessage("TEST: Text").With(robot.AtMobiles("19900001111")),
+			`{"msgtype":"text","at":{"atMobiles":["19900001111"]},"text":{"content":"TEST: Text"}}`,
+		},
+		{
+			"AtUserIDs",
+			dingtalk.NewMarkdownMessage("TEST: Markdown", "## title").With(robot.AtUserIDs("manager1234"), robot.AtMobiles("19900001111")),
+			`{"msgtype":"markdown","at":{"atMobiles":["19900001111"],"atUserIds":["manager1234"]},"markdown":{"title":"TEST: Markdown","text":"## title"}}`,
+		},
+		{
+			"Link",
+			dingtalk.NewLinkMessage("TEST: Link", "link content", "https://github.com/shockerli", ""),
+			`{"msgtype":"link","link":{"title":"TEST: Link","text":"link content","messageUrl":"https://github.com/shockerli"}}`,
+		},
+		{
+			"Markdown",
+			dingtalk.NewMarkdownMessage("TEST: Markdown", "## title").With(robot.AtAll()),
+			`{"msgtype":"markdown","at":{"isAtAll":true},"markdown":{"title":"TEST: Markdown","text":"## title"}}`,
+		},
+		{
+			"ActionCard",
+			dingtalk.NewActionCardMessage("TEST: ActionCard", "content").With(robot.SingleCard("阅读全文", "https://github.com/shockerli")),
+			`{"msgtype":"actionCard","actionCard":{"title":"TEST: ActionCard","text":"content","singleTitle":"阅读全文","singleURL":"https://github.com/shockerli","hideAvatar":"0","btnOrientation":"1"}}`,
+		},
+		{
+			"FeedCard",
+			dingtalk.NewFeedCardMessage().With(robot.FeedCard("title", "https://github.com/shockerli", "https://github.com/shockerli.png")),
+			`{"msgtype":"feedCard","feedCard":{"links":[{"title":"title","messageURL":"https://github.com/shockerli","picURL":"https://github.com/shockerli.png"}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.msg.Marshal()
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRobotCustom_Send(t *testing.T) {
+	srv := dingtalktest.NewServer("SECtest")
+	defer srv.Close()
+
+	rc := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SetSecret(srv.Secret)
+	rc.Use(func(next dingtalk.Sender) dingtalk.Sender {
+		return dingtalk.SenderFunc(func(ctx context.Context, msg *dingtalk.Message) error {
+			msg.Text.Content = "rewritten"
+			return next.Send(ctx, msg)
+		})
+	})
+
+	msg := dingtalk.NewTextMessage("TEST: Send")
+	if err := rc.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if msg.Text.Content != "TEST: Send" {
+		t.Errorf("Send() modified msg, content = %q", msg.Text.Content)
+	}
+	if n := len(srv.Messages()); n != 1 {
+		t.Errorf("len(Messages()) = %d, want 1", n)
+	}
+}
+
+func TestMessage_Err(t *testing.T) {
+	msg := dingtalk.NewLinkMessage("TEST: Link", "link content", "https://github.com/shockerli", "").
+		With(robot.AtAll(), robot.HideAvatar("1"), robot.AtUserIDs("manager1234"))
+	err := msg.Err()
+	if !errors.Is(err, dingtalk.ErrOptionNotApplicable) {
+		t.Fatalf("Err() = %v, want ErrOptionNotApplicable", err)
+	}
+	if !strings.Contains(err.Error(), "AtAll") || !strings.Contains(err.Error(), "HideAvatar") {
+		t.Errorf("Err() = %v, want both options", err)
+	}
+	if msg.At != nil {
+		t.Errorf("At = %+v, want nil", msg.At)
+	}
+
+	if err := dingtalk.NewTextMessage("TEST: Text").With(robot.AtAll()).Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+}
+
+func TestRobotCustom_OptionNotApplicable(t *testing.T) {
+	srv := dingtalktest.NewServer("SECtest")
+	defer srv.Close()
+
+	rc := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SetSecret(srv.Secret)
+	err := rc.SendLink("TEST: Link", "link content", "https://github.com/shockerli", "", rc.AtAll())
+	if !errors.Is(err, dingtalk.ErrOptionNotApplicable) {
+		t.Fatalf("SendLink() error = %v, want ErrOptionNotApplicable", err)
+	}
+	if n := len(srv.Messages()); n != 0 {
+		t.Fatalf("len(Messages()) = %d, want 0", n)
+	}
+
+	lr := &logRecorder{}
+	rc.SetLenientOptions(true).SetLogger(lr)
+	if err := rc.SendLink("TEST: Link", "link content", "https://github.com/shockerli", "", rc.AtAll()); err != nil {
+		t.Fatalf("SendLink() error = %v", err)
+	}
+	if n := len(srv.Messages()); n != 1 {
+		t.Errorf("len(Messages()) = %d, want 1", n)
+	}
+	if !strings.Contains(lr.String(), "WARN dingtalk: option ignored") {
+		t.Errorf("log = %q, want option ignored warning", lr.String())
+	}
+}
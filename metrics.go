@@ -0,0 +1,60 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// 发送结果
+const (
+	OutcomeSuccess  = "success"  // 发送成功
+	OutcomeFailure  = "failure"  // 接口或网络错误
+	OutcomeRejected = "rejected" // 因本地限流(含丢弃)或熔断未发送
+)
+
+// Metrics 发送指标采集
+type Metrics interface {
+	// ObserveSend 记录一次发送
+	ObserveSend(m SendMetric)
+}
+
+// SendMetric 一次发送的指标
+type SendMetric struct {
+	Robot    string        // 机器人名称
+	MsgType  string        // 消息类型
+	Outcome  string        // 发送结果
+	ErrCode  int           // 接口错误码，非接口错误时为0
+	Duration time.Duration // 耗时，包含重试及等待限流的时间
+}
+
+// MetricsMiddleware 指标采集中间件
+func MetricsMiddleware(robot string, m Metrics) Middleware {
+	return func(next Sender) Sender {
+		return SenderFunc(func(ctx context.Context, msg *Message) error {
+			start := time.Now()
+			err := next.Send(ctx, msg)
+
+			metric := SendMetric{
+				Robot:    robot,
+				MsgType:  msg.MsgType,
+				Outcome:  OutcomeSuccess,
+				Duration: time.Since(start),
+			}
+			var re *RobotError
+			switch {
+			case err == nil:
+			case errors.As(err, &re):
+				metric.Outcome = OutcomeFailure
+				metric.ErrCode = re.ErrCode
+			case isLocalLimit(err), errors.Is(err, ErrCircuitOpen):
+				metric.Outcome = OutcomeRejected
+			default:
+				metric.Outcome = OutcomeFailure
+			}
+			m.ObserveSend(metric)
+
+			return err
+		})
+	}
+}
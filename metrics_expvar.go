@@ -0,0 +1,60 @@
+package dingtalk
+
+import (
+	"expvar"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ExpvarMetrics 通过expvar输出的指标
+//
+// 指标(键以"/"分隔):
+// 	<name>.messages           robot/type/outcome/errcode -> 发送消息数
+// 	<name>.duration_seconds   robot/type -> 累计耗时(秒)
+// 	<name>.duration_count     robot/type -> 耗时记录数
+type ExpvarMetrics struct {
+	messages *expvar.Map
+	duration *expvar.Map
+	count    *expvar.Map
+}
+
+// NewExpvarMetrics 实例化并注册expvar变量，name相同的实例共用已注册的变量
+//
+// 示例:
+// 	robot.SetMetrics(dingtalk.NewExpvarMetrics("dingtalk"))
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	return &ExpvarMetrics{
+		messages: expvarMap(name + ".messages"),
+		duration: expvarMap(name + ".duration_seconds"),
+		count:    expvarMap(name + ".duration_count"),
+	}
+}
+
+// 避免并发注册同名变量
+var expvarMu sync.Mutex
+
+// 获取已注册的expvar.Map，不存在时注册；同名变量不是expvar.Map时panic
+func expvarMap(name string) *expvar.Map {
+	v := expvar.Get(name)
+	if v == nil {
+		return expvar.NewMap(name)
+	}
+	m, ok := v.(*expvar.Map)
+	if !ok {
+		panic("dingtalk: expvar " + name + " is not a *expvar.Map")
+	}
+	return m
+}
+
+// ObserveSend 记录一次发送
+func (em *ExpvarMetrics) ObserveSend(m SendMetric) {
+	em.messages.Add(strings.Join([]string{m.Robot, m.MsgType, m.Outcome, strconv.Itoa(m.ErrCode)}, "/"), 1)
+
+	key := m.Robot + "/" + m.MsgType
+	em.duration.AddFloat(key, m.Duration.Seconds())
+	em.count.Add(key, 1)
+}
@@ -0,0 +1,133 @@
+package dingtalk
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 默认耗时分布区间(秒)
+var defaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10}
+
+// PrometheusMetrics 以Prometheus文本格式输出的指标
+//
+// 指标:
+// 	dingtalk_robot_messages_total{robot,type,outcome,errcode}  发送消息数
+// 	dingtalk_robot_send_duration_seconds{robot,type}            发送耗时分布
+type PrometheusMetrics struct {
+	mu       sync.Mutex
+	buckets  []float64
+	counters map[[4]string]uint64         // robot,type,outcome,errcode
+	hists    map[[2]string]*promHistogram // robot,type
+}
+
+// 耗时分布
+type promHistogram struct {
+	counts []uint64 // 各区间的累计数量
+	sum    float64
+	count  uint64
+}
+
+// NewPrometheusMetrics 实例化，buckets为耗时分布区间(秒)，为空时使用默认区间
+//
+// 示例:
+// 	pm := dingtalk.NewPrometheusMetrics()
+// 	robot.SetMetrics(pm)
+// 	http.Handle("/metrics", pm)
+func NewPrometheusMetrics(buckets ...float64) *PrometheusMetrics {
+	if len(buckets) == 0 {
+		buckets = defaultBuckets
+	}
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+
+	return &PrometheusMetrics{
+		buckets:  buckets,
+		counters: make(map[[4]string]uint64),
+		hists:    make(map[[2]string]*promHistogram),
+	}
+}
+
+// ObserveSend 记录一次发送
+func (pm *PrometheusMetrics) ObserveSend(m SendMetric) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.counters[[4]string{m.Robot, m.MsgType, m.Outcome, strconv.Itoa(m.ErrCode)}]++
+
+	key := [2]string{m.Robot, m.MsgType}
+	h, ok := pm.hists[key]
+	if !ok {
+		h = &promHistogram{counts: make([]uint64, len(pm.buckets))}
+		pm.hists[key] = h
+	}
+	seconds := m.Duration.Seconds()
+	for i, le := range pm.buckets {
+		if seconds <= le {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// ServeHTTP 输出Prometheus文本格式的指标
+func (pm *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(pm.export())
+}
+
+// 生成Prometheus文本格式的指标
+func (pm *PrometheusMetrics) export() []byte {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	var buf bytes.Buffer
+
+	buf.WriteString("# HELP dingtalk_robot_messages_total Total number of robot messages sent.\n")
+	buf.WriteString("# TYPE dingtalk_robot_messages_total counter\n")
+	counterKeys := make([][4]string, 0, len(pm.counters))
+	for k := range pm.counters {
+		counterKeys = append(counterKeys, k)
+	}
+	sort.Slice(counterKeys, func(i, j int) bool {
+		return strings.Join(counterKeys[i][:], "\x00") < strings.Join(counterKeys[j][:], "\x00")
+	})
+	for _, k := range counterKeys {
+		fmt.Fprintf(&buf, "dingtalk_robot_messages_total{robot=%s,type=%s,outcome=%s,errcode=%s} %d\n",
+			promLabel(k[0]), promLabel(k[1]), promLabel(k[2]), promLabel(k[3]), pm.counters[k])
+	}
+
+	buf.WriteString("# HELP dingtalk_robot_send_duration_seconds Robot message send duration in seconds.\n")
+	buf.WriteString("# TYPE dingtalk_robot_send_duration_seconds histogram\n")
+	histKeys := make([][2]string, 0, len(pm.hists))
+	for k := range pm.hists {
+		histKeys = append(histKeys, k)
+	}
+	sort.Slice(histKeys, func(i, j int) bool {
+		return strings.Join(histKeys[i][:], "\x00") < strings.Join(histKeys[j][:], "\x00")
+	})
+	for _, k := range histKeys {
+		h := pm.hists[k]
+		labels := fmt.Sprintf("robot=%s,type=%s", promLabel(k[0]), promLabel(k[1]))
+		for i, le := range pm.buckets {
+			fmt.Fprintf(&buf, "dingtalk_robot_send_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, strconv.FormatFloat(le, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(&buf, "dingtalk_robot_send_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(&buf, "dingtalk_robot_send_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&buf, "dingtalk_robot_send_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	return buf.Bytes()
+}
+
+// 转义标签值
+func promLabel(v string) string {
+	v = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+	return `"` + v + `"`
+}
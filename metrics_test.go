@@ -0,0 +1,89 @@
+package dingtalk_test
+
+import (
+	"expvar"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shockerli/dingtalk"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	pm := dingtalk.NewPrometheusMetrics()
+	rc := dingtalk.NewRobotCustom().
+		SetName("alert").
+		SetWebhook("https://oapi.dingtalk.com/robot/send?access_token=test").
+		SetTransport(okTransport()).
+		SetRateLimit(1, time.Minute, dingtalk.RateLimitFailFast).
+		SetMetrics(pm)
+
+	_ = rc.SendText("TEST: Metrics")
+	_ = rc.SendText("TEST: Metrics")
+
+	w := httptest.NewRecorder()
+	pm.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(w.Body)
+
+	for _, want := range []string{
+		`dingtalk_robot_messages_total{robot="alert",type="text",outcome="success",errcode="0"} 1`,
+		`dingtalk_robot_messages_total{robot="alert",type="text",outcome="rejected",errcode="0"} 1`,
+		`dingtalk_robot_send_duration_seconds_bucket{robot="alert",type="text",le="+Inf"} 2`,
+		`dingtalk_robot_send_duration_seconds_count{robot="alert",type="text"} 2`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics output does not contain %q\n%s", want, body)
+		}
+	}
+}
+
+func TestExpvarMetrics(t *testing.T) {
+	// 变量全局注册，每次运行使用不同的名称
+	name := fmt.Sprintf("dingtalk_test_%d", time.Now().UnixNano())
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook("https://oapi.dingtalk.com/robot/send?access_token=test").
+		SetTransport(okTransport()).
+		SetMetrics(dingtalk.NewExpvarMetrics(name))
+
+	_ = rc.SendText("TEST: Metrics")
+
+	// 同名实例共用已注册的变量
+	dingtalk.NewExpvarMetrics(name).ObserveSend(dingtalk.SendMetric{Robot: "default", MsgType: "text", Outcome: dingtalk.OutcomeSuccess})
+
+	messages := expvar.Get(name + ".messages").(*expvar.Map)
+	if v := messages.Get("default/text/success/0"); v == nil || v.String() != "2" {
+		t.Errorf("%s.messages = %v", name, messages)
+	}
+}
+
+func TestMetricsMiddleware_Dropped(t *testing.T) {
+	var metrics []dingtalk.SendMetric
+	lr := &logRecorder{}
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook("https://oapi.dingtalk.com/robot/send?access_token=test").
+		SetTransport(okTransport()).
+		SetRateLimit(1, time.Minute, dingtalk.RateLimitDrop).
+		SetLogger(lr).
+		SetMetrics(metricsFunc(func(m dingtalk.SendMetric) { metrics = append(metrics, m) }))
+
+	for i := 0; i < 2; i++ {
+		if err := rc.SendText("TEST: Metrics"); err != nil {
+			t.Fatalf("SendText() error = %v", err)
+		}
+	}
+
+	// 丢弃的消息记为未发送
+	if len(metrics) != 2 || metrics[0].Outcome != dingtalk.OutcomeSuccess || metrics[1].Outcome != dingtalk.OutcomeRejected {
+		t.Errorf("metrics = %+v, want success and rejected", metrics)
+	}
+	if !strings.Contains(lr.String(), "WARN dingtalk: message rejected") {
+		t.Errorf("log = %q, want rejected warning", lr.String())
+	}
+}
+
+type metricsFunc func(dingtalk.SendMetric)
+
+func (f metricsFunc) ObserveSend(m dingtalk.SendMetric) { f(m) }
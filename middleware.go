@@ -0,0 +1,105 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Sender 消息发送器
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// 可作为Sender使用的类型
+var (
+	_ Sender = (*RobotCustom)(nil)
+	_ Sender = (*RobotPool)(nil)
+	_ Sender = (*RobotDedup)(nil)
+)
+
+// SenderFunc 函数形式的Sender
+type SenderFunc func(ctx context.Context, msg *Message) error
+
+// Send 发送消息
+func (f SenderFunc) Send(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// Middleware 发送中间件，可用于日志、监控、消息改写、限流、重试等
+type Middleware func(next Sender) Sender
+
+// Chain 将中间件依次包裹在Sender外，第一个中间件位于最外层
+func Chain(s Sender, mws ...Middleware) Sender {
+	for i := len(mws) - 1; i >= 0; i-- {
+		s = mws[i](s)
+	}
+	return s
+}
+
+// RateLimitMiddleware 频率限制中间件
+//
+// RateLimitDrop 模式下丢弃的消息同样返回错误，以便外层的日志、指标等记录为未发送，
+// RobotCustom 在最外层将其转换为nil
+func RateLimitMiddleware(l *RateLimiter) Middleware {
+	return func(next Sender) Sender {
+		return SenderFunc(func(ctx context.Context, msg *Message) error {
+			if err := l.acquire(ctx); err != nil {
+				return err
+			}
+			return next.Send(ctx, msg)
+		})
+	}
+}
+
+// RetryMiddleware 重试中间件，每次重试均重新调用内层Sender
+func RetryMiddleware(p RetryPolicy) Middleware {
+	return func(next Sender) Sender {
+		return SenderFunc(func(ctx context.Context, msg *Message) error {
+			return p.do(ctx, func(ctx context.Context) error {
+				return next.Send(ctx, msg)
+			})
+		})
+	}
+}
+
+// CircuitBreakerMiddleware 熔断中间件
+func CircuitBreakerMiddleware(cb *CircuitBreaker) Middleware {
+	return func(next Sender) Sender {
+		return SenderFunc(func(ctx context.Context, msg *Message) (err error) {
+			t, err := cb.allow()
+			if err != nil {
+				return err
+			}
+			defer func() { cb.record(t, err) }()
+
+			return next.Send(ctx, msg)
+		})
+	}
+}
+
+// LoggingMiddleware 日志中间件，记录每条消息的发送结果及耗时
+func LoggingMiddleware(robot string, l Logger) Middleware {
+	return func(next Sender) Sender {
+		return SenderFunc(func(ctx context.Context, msg *Message) error {
+			start := time.Now()
+			err := next.Send(ctx, msg)
+
+			kv := []interface{}{"robot", robot, "msgtype", msg.MsgType, "duration", time.Since(start)}
+			var re *RobotError
+			switch {
+			case err == nil:
+				l.Log(ctx, LogInfo, "dingtalk: message sent", kv...)
+			case errors.As(err, &re):
+				kv = append(kv, "errcode", re.ErrCode, "errmsg", re.ErrMsg, "status", re.StatusCode, "request_id", re.RequestID)
+				l.Log(ctx, LogError, "dingtalk: message failed", kv...)
+			case isLocalLimit(err), errors.Is(err, ErrCircuitOpen):
+				l.Log(ctx, LogWarn, "dingtalk: message rejected", append(kv, "error", logError(err))...)
+			default:
+				l.Log(ctx, LogError, "dingtalk: message failed", append(kv, "error", logError(err))...)
+			}
+
+			return err
+		})
+	}
+}
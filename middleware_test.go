@@ -0,0 +1,47 @@
+package dingtalk_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shockerli/dingtalk"
+)
+
+func TestRobotCustom_Use(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	var calls []string
+	trace := func(name string) dingtalk.Middleware {
+		return func(next dingtalk.Sender) dingtalk.Sender {
+			return dingtalk.SenderFunc(func(ctx context.Context, msg *dingtalk.Message) error {
+				calls = append(calls, name)
+				return next.Send(ctx, msg)
+			})
+		}
+	}
+	redact := func(next dingtalk.Sender) dingtalk.Sender {
+		return dingtalk.SenderFunc(func(ctx context.Context, msg *dingtalk.Message) error {
+			msg.Text.Content = strings.Replace(msg.Text.Content, "password", "******", -1)
+			return next.Send(ctx, msg)
+		})
+	}
+
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook(srv.URL+"/robot/send?access_token=test").
+		Use(trace("outer"), trace("inner"), redact)
+
+	if err := rc.SendText("TEST: password"); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+	if strings.Join(calls, ",") != "outer,inner" {
+		t.Errorf("calls = %v, want [outer inner]", calls)
+	}
+	content := rec.all()[0]["text"].(map[string]interface{})["content"]
+	if content != "TEST: ******" {
+		t.Errorf("content = %v, want %q", content, "TEST: ******")
+	}
+}
@@ -0,0 +1,303 @@
+package dingtalk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Outbox 待发送消息的持久化存储
+type Outbox interface {
+	// Put 写入待发送的消息，返回消息ID
+	Put(payload []byte) (id string, err error)
+	// Ack 确认消息已发送成功
+	Ack(id string) error
+	// Pending 按写入顺序返回所有未确认的消息
+	Pending() ([]OutboxEntry, error)
+}
+
+// OutboxEntry 未确认的消息
+type OutboxEntry struct {
+	ID      string // 消息ID
+	Payload []byte // 消息内容(JSON)
+}
+
+// 已确认记录超过该数量时压缩文件
+const outboxCompactThreshold = 1000
+
+// Outbox文件中的记录，每行一条
+type outboxRecord struct {
+	Op      string          `json:"op"` // put/ack
+	ID      string          `json:"id"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// FileOutbox 基于文件的Outbox
+//
+// 以追加方式写入记录，每次写入后fsync；已确认的记录累积到一定数量后自动压缩
+type FileOutbox struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	ids     []string          // 未确认的消息ID，按写入顺序
+	pending map[string][]byte // 未确认的消息
+	acked   int               // 文件中已确认的记录数
+	seq     uint64
+}
+
+// OpenFileOutbox 打开Outbox文件，不存在时创建
+//
+// 示例:
+// 	ob, err := dingtalk.OpenFileOutbox("/var/lib/app/dingtalk.outbox")
+func OpenFileOutbox(path string) (*FileOutbox, error) {
+	o := &FileOutbox{
+		path:    path,
+		pending: make(map[string][]byte),
+	}
+	size, err := o.load()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	// 截掉末尾写入不完整的记录，避免后续记录与其写在同一行
+	fi, err := f.Stat()
+	if err == nil && fi.Size() > size {
+		if err = f.Truncate(size); err == nil {
+			err = f.Sync()
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	o.file = f
+	return o, nil
+}
+
+// Put 写入待发送的消息
+func (o *FileOutbox) Put(payload []byte) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.seq++
+	id := fmt.Sprintf("%d-%d", time.Now().UnixNano(), o.seq)
+	if err := o.write(outboxRecord{Op: "put", ID: id, Payload: payload}); err != nil {
+		return "", err
+	}
+
+	o.ids = append(o.ids, id)
+	o.pending[id] = payload
+	return id, nil
+}
+
+// Ack 确认消息已发送成功
+func (o *FileOutbox) Ack(id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, ok := o.pending[id]; !ok {
+		return nil
+	}
+	if err := o.write(outboxRecord{Op: "ack", ID: id}); err != nil {
+		return err
+	}
+
+	delete(o.pending, id)
+	for i, v := range o.ids {
+		if v == id {
+			o.ids = append(o.ids[:i], o.ids[i+1:]...)
+			break
+		}
+	}
+
+	o.acked++
+	if o.acked >= outboxCompactThreshold {
+		return o.compact()
+	}
+	return nil
+}
+
+// Pending 按写入顺序返回所有未确认的消息
+func (o *FileOutbox) Pending() ([]OutboxEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entries := make([]OutboxEntry, 0, len(o.ids))
+	for _, id := range o.ids {
+		entries = append(entries, OutboxEntry{ID: id, Payload: o.pending[id]})
+	}
+	return entries, nil
+}
+
+// Compact 压缩文件，仅保留未确认的消息
+func (o *FileOutbox) Compact() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.compact()
+}
+
+// Close 关闭文件
+func (o *FileOutbox) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.file.Close()
+}
+
+// 读取文件中的记录，忽略写入不完整的记录，返回最后一条完整记录的结束位置
+func (o *FileOutbox) load() (int64, error) {
+	f, err := os.Open(o.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var size int64
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		size += int64(len(line))
+
+		var rec outboxRecord
+		if json.Unmarshal(line, &rec) != nil {
+			continue
+		}
+		switch rec.Op {
+		case "put":
+			o.ids = append(o.ids, rec.ID)
+			o.pending[rec.ID] = rec.Payload
+		case "ack":
+			if _, ok := o.pending[rec.ID]; ok {
+				delete(o.pending, rec.ID)
+				o.acked++
+			}
+		}
+	}
+
+	ids := o.ids[:0]
+	for _, id := range o.ids {
+		if _, ok := o.pending[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	o.ids = ids
+	return size, nil
+}
+
+// 追加一条记录并fsync
+func (o *FileOutbox) write(rec outboxRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err = o.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return o.file.Sync()
+}
+
+// 将未确认的消息写入临时文件后替换原文件
+func (o *FileOutbox) compact() error {
+	tmp := o.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	for _, id := range o.ids {
+		line, err := json.Marshal(outboxRecord{Op: "put", ID: id, Payload: o.pending[id]})
+		if err != nil {
+			f.Close()
+			return err
+		}
+		_, _ = w.Write(append(line, '\n'))
+	}
+	if err = w.Flush(); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp, o.path); err != nil {
+		return err
+	}
+	_ = o.file.Close()
+	o.file, err = os.OpenFile(o.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	o.acked = 0
+	return nil
+}
+
+// 是否为被接口拒绝、重试也无法成功的错误
+func isRejected(err error) bool {
+	var re *RobotError
+	return errors.As(err, &re) && !IsTemporary(err)
+}
+
+// 机器人池中单个机器人发送失败后，其Outbox中未确认的消息
+type outboxAcks struct {
+	mu      sync.Mutex
+	entries []outboxAck
+}
+
+type outboxAck struct {
+	outbox Outbox
+	id     string
+}
+
+type outboxAcksKey struct{}
+
+func outboxAcksFromContext(ctx context.Context) *outboxAcks {
+	acks, _ := ctx.Value(outboxAcksKey{}).(*outboxAcks)
+	return acks
+}
+
+func (a *outboxAcks) add(ob Outbox, id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.entries = append(a.entries, outboxAck{outbox: ob, id: id})
+}
+
+// 确认记录的消息
+func (a *outboxAcks) ack() error {
+	a.mu.Lock()
+	entries := a.entries
+	a.entries = nil
+	a.mu.Unlock()
+
+	var err error
+	for _, e := range entries {
+		if aerr := e.outbox.Ack(e.id); aerr != nil && err == nil {
+			err = aerr
+		}
+	}
+	return err
+}
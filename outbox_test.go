@@ -0,0 +1,238 @@
+package dingtalk_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shockerli/dingtalk"
+	"github.com/shockerli/dingtalk/dingtalktest"
+)
+
+func TestFileOutbox(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dingtalk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "outbox")
+
+	// 发送失败，消息保留在Outbox中
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	ob, err := dingtalk.OpenFileOutbox(path)
+	if err != nil {
+		t.Fatalf("OpenFileOutbox() error = %v", err)
+	}
+	rc := dingtalk.NewRobotCustom().SetWebhook(down.URL + "/robot/send?access_token=test").SetOutbox(ob)
+	if err = rc.SendText("TEST: Outbox"); err == nil {
+		t.Fatalf("SendText() error = nil, want error")
+	}
+	down.Close()
+	_ = ob.Close()
+
+	// 重启后重新发送
+	rec := &recorder{}
+	up := httptest.NewServer(rec)
+	defer up.Close()
+	ob, err = dingtalk.OpenFileOutbox(path)
+	if err != nil {
+		t.Fatalf("OpenFileOutbox() error = %v", err)
+	}
+	defer ob.Close()
+	if entries, _ := ob.Pending(); len(entries) != 1 {
+		t.Fatalf("len(Pending()) = %d, want 1", len(entries))
+	}
+
+	rc = dingtalk.NewRobotCustom().SetWebhook(up.URL + "/robot/send?access_token=test").SetOutbox(ob)
+	if err = rc.ReplayOutbox(context.Background()); err != nil {
+		t.Fatalf("ReplayOutbox() error = %v", err)
+	}
+	messages := rec.all()
+	if len(messages) != 1 || messages[0]["text"].(map[string]interface{})["content"] != "TEST: Outbox" {
+		t.Fatalf("replayed messages = %v", messages)
+	}
+	if entries, _ := ob.Pending(); len(entries) != 0 {
+		t.Errorf("len(Pending()) = %d, want 0", len(entries))
+	}
+
+	// 压缩后文件为空
+	if err = ob.Compact(); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	if strings.TrimSpace(string(data)) != "" {
+		t.Errorf("compacted outbox = %q, want empty", data)
+	}
+}
+
+func openTestOutbox(t *testing.T, dir, name string) *dingtalk.FileOutbox {
+	t.Helper()
+	ob, err := dingtalk.OpenFileOutbox(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("OpenFileOutbox() error = %v", err)
+	}
+	return ob
+}
+
+func TestFileOutbox_Rejected(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dingtalk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srv := dingtalktest.NewServer("")
+	defer srv.Close()
+	ob := openTestOutbox(t, dir, "outbox")
+	defer ob.Close()
+	lr := &logRecorder{}
+	rc := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SetOutbox(ob).SetLogger(lr)
+
+	// 被接口拒绝的消息不保留
+	srv.Respond(dingtalktest.Response{ErrCode: 310000, ErrMsg: "keywords not in content"})
+	if err = rc.SendText("TEST: Rejected"); !dingtalk.IsKeywordMismatch(err) {
+		t.Fatalf("SendText() error = %v, want keyword mismatch", err)
+	}
+	if entries, _ := ob.Pending(); len(entries) != 0 {
+		t.Fatalf("len(Pending()) = %d, want 0", len(entries))
+	}
+	if out := lr.String(); !strings.Contains(out, "WARN dingtalk: outbox entry discarded [robot default id") {
+		t.Errorf("log does not contain discarded entry\n%s", out)
+	}
+
+	// 临时错误的消息保留
+	for i := 0; i < 3; i++ {
+		srv.Respond(dingtalktest.Response{StatusCode: http.StatusServiceUnavailable})
+		if err = rc.SendText(fmt.Sprintf("TEST: Outbox %d", i)); err == nil {
+			t.Fatal("SendText() error = nil, want error")
+		}
+	}
+	if entries, _ := ob.Pending(); len(entries) != 3 {
+		t.Fatalf("len(Pending()) = %d, want 3", len(entries))
+	}
+
+	// 重新发送时跳过被拒绝的消息，继续发送其余消息
+	srv.Respond(dingtalktest.Response{ErrCode: 310000, ErrMsg: "keywords not in content"})
+	if err = rc.ReplayOutbox(context.Background()); !dingtalk.IsKeywordMismatch(err) {
+		t.Fatalf("ReplayOutbox() error = %v, want keyword mismatch", err)
+	}
+	if n := len(srv.Messages()); n != 2 {
+		t.Errorf("len(Messages()) = %d, want 2", n)
+	}
+	if entries, _ := ob.Pending(); len(entries) != 0 {
+		t.Errorf("len(Pending()) = %d, want 0", len(entries))
+	}
+}
+
+func TestFileOutbox_PoolFailover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dingtalk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srv := dingtalktest.NewServer("")
+	defer srv.Close()
+	obA, obB := openTestOutbox(t, dir, "a"), openTestOutbox(t, dir, "b")
+	defer obA.Close()
+	defer obB.Close()
+	pool := dingtalk.NewRobotPool(dingtalk.PoolRoundRobin,
+		dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SetOutbox(obA),
+		dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SetOutbox(obB),
+	)
+
+	// 切换后发送成功，确认第一个机器人Outbox中的消息
+	srv.Respond(dingtalktest.Response{ErrCode: 410100, ErrMsg: "send too fast, exceed 20 times per minute"})
+	if err = pool.SendText("TEST: Pool"); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+	a, _ := obA.Pending()
+	b, _ := obB.Pending()
+	if len(a) != 0 || len(b) != 0 {
+		t.Errorf("len(Pending()) = %d, %d, want 0, 0", len(a), len(b))
+	}
+
+	// 均失败时仅保留最后一个机器人Outbox中的消息
+	srv.Respond(
+		dingtalktest.Response{ErrCode: 410100, ErrMsg: "send too fast, exceed 20 times per minute"},
+		dingtalktest.Response{ErrCode: 410100, ErrMsg: "send too fast, exceed 20 times per minute"},
+	)
+	if err = pool.SendText("TEST: Pool"); !dingtalk.IsRateLimited(err) {
+		t.Fatalf("SendText() error = %v, want rate limited", err)
+	}
+	a, _ = obA.Pending()
+	b, _ = obB.Pending()
+	if len(a)+len(b) != 1 {
+		t.Errorf("len(Pending()) = %d, %d, want 1 in total", len(a), len(b))
+	}
+}
+
+func TestFileOutbox_Dropped(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dingtalk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ob := openTestOutbox(t, dir, "outbox")
+	defer ob.Close()
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook("https://oapi.dingtalk.com/robot/send?access_token=test").
+		SetTransport(okTransport()).
+		SetRateLimit(1, time.Minute, dingtalk.RateLimitDrop).
+		SetOutbox(ob)
+
+	// 本地限流丢弃的消息保留在Outbox中
+	for i := 0; i < 2; i++ {
+		if err = rc.SendText("TEST: Dropped"); err != nil {
+			t.Fatalf("SendText() error = %v", err)
+		}
+	}
+	if entries, _ := ob.Pending(); len(entries) != 1 {
+		t.Errorf("len(Pending()) = %d, want 1", len(entries))
+	}
+}
+
+func TestFileOutbox_TornWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dingtalk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "outbox")
+
+	// 模拟写入过程中崩溃，文件末尾为不完整的记录
+	ob := openTestOutbox(t, dir, "outbox")
+	if _, err = ob.Put([]byte(`{"msgtype":"text","text":{"content":"TEST: 1"}}`)); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	_ = ob.Close()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"op":"put","id":"torn","payload":{"msgtype"`)
+	_ = f.Close()
+
+	// 重新打开后写入的记录不受影响
+	ob = openTestOutbox(t, dir, "outbox")
+	if _, err = ob.Put([]byte(`{"msgtype":"text","text":{"content":"TEST: 2"}}`)); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	_ = ob.Close()
+
+	ob = openTestOutbox(t, dir, "outbox")
+	defer ob.Close()
+	if entries, _ := ob.Pending(); len(entries) != 2 {
+		t.Errorf("len(Pending()) = %d, want 2", len(entries))
+	}
+}
@@ -0,0 +1,244 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrPoolEmpty 机器人池中没有机器人
+var ErrPoolEmpty = errors.New("群机器人池为空")
+
+// 群机器人触发服务端限流后的锁定时长
+const rateLimitLockDuration = 10 * time.Minute
+
+// PoolStrategy 机器人池选择机器人的策略
+type PoolStrategy int
+
+// 机器人池选择机器人的策略
+const (
+	PoolRoundRobin        PoolStrategy = iota // 轮询
+	PoolLeastRecentlyUsed                     // 最久未使用优先
+	PoolQuotaAware                            // 剩余额度最多优先，需为机器人设置频率限制
+)
+
+// RobotPool 群机器人池
+//
+// 同一群内添加多个自定义机器人，按策略分摊消息，
+// 某个机器人限流、access_token无效或熔断时自动切换到下一个；
+// 自动拆分的消息切换后从失败的一条继续发送，池中机器人的拆分长度应一致
+type RobotPool struct {
+	mu       sync.Mutex
+	strategy PoolStrategy
+	members  []*poolMember
+	next     int // 轮询位置
+}
+
+// 机器人池成员
+type poolMember struct {
+	robot    *RobotCustom
+	lastUsed time.Time
+	locked   time.Time // 服务端限流的解除时间
+}
+
+// NewRobotPool 实例化
+//
+// 示例:
+// 	pool := dingtalk.NewRobotPool(dingtalk.PoolRoundRobin,
+// 		dingtalk.NewRobotCustom().SetWebhook("webhook1").SetSecret("secret1"),
+// 		dingtalk.NewRobotCustom().SetWebhook("webhook2").SetSecret("secret2"),
+// 	)
+// 	pool.SendText("TEST: Pool")
+func NewRobotPool(strategy PoolStrategy, robots ...*RobotCustom) *RobotPool {
+	p := &RobotPool{strategy: strategy}
+	for _, rc := range robots {
+		p.Add(rc)
+	}
+	return p
+}
+
+// Add 添加机器人
+func (p *RobotPool) Add(rc *RobotCustom) *RobotPool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.members = append(p.members, &poolMember{robot: rc})
+	return p
+}
+
+// Len 机器人数量
+func (p *RobotPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.members)
+}
+
+// SendText 发送Text消息
+func (p *RobotPool) SendText(content string, opts ...RobotOption) error {
+	return p.SendTextContext(context.Background(), content, opts...)
+}
+
+// SendTextContext 发送Text消息，请求受ctx的超时及取消控制
+func (p *RobotPool) SendTextContext(ctx context.Context, content string, opts ...RobotOption) error {
+	return p.send(ctx, NewTextMessage(content), opts...)
+}
+
+// SendLink 发送Link消息
+func (p *RobotPool) SendLink(title, text, msgURL, picURL string, opts ...RobotOption) error {
+	return p.SendLinkContext(context.Background(), title, text, msgURL, picURL, opts...)
+}
+
+// SendLinkContext 发送Link消息，请求受ctx的超时及取消控制
+func (p *RobotPool) SendLinkContext(ctx context.Context, title, text, msgURL, picURL string, opts ...RobotOption) error {
+	return p.send(ctx, NewLinkMessage(title, text, msgURL, picURL), opts...)
+}
+
+// SendMarkdown 发送Markdown消息
+func (p *RobotPool) SendMarkdown(title, text string, opts ...RobotOption) error {
+	return p.SendMarkdownContext(context.Background(), title, text, opts...)
+}
+
+// SendMarkdownContext 发送Markdown消息，请求受ctx的超时及取消控制
+func (p *RobotPool) SendMarkdownContext(ctx context.Context, title, text string, opts ...RobotOption) error {
+	return p.send(ctx, NewMarkdownMessage(title, text), opts...)
+}
+
+// SendActionCard 发送ActionCard消息
+func (p *RobotPool) SendActionCard(title, text string, opts ...RobotOption) error {
+	return p.SendActionCardContext(context.Background(), title, text, opts...)
+}
+
+// SendActionCardContext 发送ActionCard消息，请求受ctx的超时及取消控制
+func (p *RobotPool) SendActionCardContext(ctx context.Context, title, text string, opts ...RobotOption) error {
+	return p.send(ctx, NewActionCardMessage(title, text), opts...)
+}
+
+// SendFeedCard 发送FeedCard消息
+func (p *RobotPool) SendFeedCard(opts ...RobotOption) error {
+	return p.SendFeedCardContext(context.Background(), opts...)
+}
+
+// SendFeedCardContext 发送FeedCard消息，请求受ctx的超时及取消控制
+func (p *RobotPool) SendFeedCardContext(ctx context.Context, opts ...RobotOption) error {
+	return p.send(ctx, NewFeedCardMessage(), opts...)
+}
+
+// Send 发送已构造的消息，msg本身不会被修改
+func (p *RobotPool) Send(ctx context.Context, msg *Message) error {
+	return p.send(ctx, msg.clone())
+}
+
+// 按策略依次尝试机器人，限流或access_token无效时切换到下一个
+func (p *RobotPool) send(ctx context.Context, msg *Message, opts ...RobotOption) error {
+	msg.With(opts...)
+
+	members := p.pick()
+	if len(members) == 0 {
+		return ErrPoolEmpty
+	}
+
+	// 其他机器人发送成功后，确认之前失败的机器人Outbox中的消息，避免重复发送；
+	// 均失败时仅保留最后一个机器人Outbox中的消息
+	var attempts []*outboxAcks
+	settle := func(err error) error {
+		n := len(attempts)
+		if err != nil {
+			n--
+		}
+		for _, acks := range attempts[:n] {
+			if aerr := acks.ack(); aerr != nil && err == nil {
+				err = aerr
+			}
+		}
+		return err
+	}
+
+	var err error
+	var from int // 拆分后的消息从失败的一条继续发送，已发送的不再重复
+	for i, m := range members {
+		if i > 0 {
+			p.touch(m)
+		}
+		acks := &outboxAcks{}
+		attempts = append(attempts, acks)
+
+		// 每次尝试使用副本，避免中间件的改写累积
+		err = m.robot.deliverFrom(context.WithValue(ctx, outboxAcksKey{}, acks), msg.clone(), from)
+		if err == nil || !p.failover(m, err) || ctx.Err() != nil {
+			return settle(err)
+		}
+		var pe *partError
+		if errors.As(err, &pe) {
+			from = pe.index
+		}
+	}
+	return settle(err)
+}
+
+// 按策略排列本次尝试的机器人顺序
+func (p *RobotPool) pick() []*poolMember {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := len(p.members)
+	if n == 0 {
+		return nil
+	}
+
+	// 以轮询顺序为基础
+	members := make([]*poolMember, 0, n)
+	for i := 0; i < n; i++ {
+		members = append(members, p.members[(p.next+i)%n])
+	}
+	p.next = (p.next + 1) % n
+
+	now := time.Now()
+	sort.SliceStable(members, func(i, j int) bool {
+		// 服务端限流中的机器人排在最后
+		li, lj := members[i].locked.After(now), members[j].locked.After(now)
+		if li != lj {
+			return lj
+		}
+		switch p.strategy {
+		case PoolLeastRecentlyUsed:
+			return members[i].lastUsed.Before(members[j].lastUsed)
+		case PoolQuotaAware:
+			return remaining(members[i].robot) > remaining(members[j].robot)
+		}
+		return false
+	})
+
+	members[0].lastUsed = now
+	return members
+}
+
+// 记录机器人的使用时间
+func (p *RobotPool) touch(m *poolMember) {
+	p.mu.Lock()
+	m.lastUsed = time.Now()
+	p.mu.Unlock()
+}
+
+// 是否切换到下一个机器人
+func (p *RobotPool) failover(m *poolMember, err error) bool {
+	var re *RobotError
+	if errors.As(err, &re) && errors.Is(re, ErrRateLimited) {
+		p.mu.Lock()
+		m.locked = time.Now().Add(rateLimitLockDuration)
+		p.mu.Unlock()
+	}
+
+	return IsRateLimited(err) || IsTokenInvalid(err) || errors.Is(err, ErrCircuitOpen)
+}
+
+// 剩余额度，未设置频率限制时视为无限
+func remaining(rc *RobotCustom) int {
+	n := rc.RateLimitRemaining()
+	if n < 0 {
+		return int(^uint(0) >> 1)
+	}
+	return n
+}
@@ -0,0 +1,152 @@
+package dingtalk_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shockerli/dingtalk"
+	"github.com/shockerli/dingtalk/dingtalktest"
+)
+
+func TestRobotPool(t *testing.T) {
+	var tokens []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("access_token")
+		tokens = append(tokens, token)
+		if token == "limited" {
+			_, _ = w.Write([]byte(`{"errcode":410100,"errmsg":"send too fast, exceed 20 times per minute"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer srv.Close()
+
+	webhook := srv.URL + "/robot/send?access_token="
+	pool := dingtalk.NewRobotPool(dingtalk.PoolRoundRobin,
+		dingtalk.NewRobotCustom().SetWebhook(webhook+"limited"),
+		dingtalk.NewRobotCustom().SetWebhook(webhook+"a"),
+		dingtalk.NewRobotCustom().SetWebhook(webhook+"b"),
+	)
+
+	for i := 0; i < 3; i++ {
+		if err := pool.SendText("TEST: Pool"); err != nil {
+			t.Fatalf("SendText() error = %v", err)
+		}
+	}
+
+	// 首次限流后切换，限流的机器人之后排在最后
+	want := []string{"limited", "a", "a", "b"}
+	if len(tokens) != len(want) {
+		t.Fatalf("tokens = %v, want %v", tokens, want)
+	}
+	for i := range want {
+		if tokens[i] != want[i] {
+			t.Fatalf("tokens = %v, want %v", tokens, want)
+		}
+	}
+}
+
+func TestRobotPool_QuotaAware(t *testing.T) {
+	a := dingtalk.NewRobotCustom().
+		SetWebhook("https://oapi.dingtalk.com/robot/send?access_token=a").
+		SetTransport(okTransport()).
+		SetRateLimit(1, time.Minute, dingtalk.RateLimitFailFast)
+	b := dingtalk.NewRobotCustom().
+		SetWebhook("https://oapi.dingtalk.com/robot/send?access_token=b").
+		SetTransport(okTransport()).
+		SetRateLimit(1, time.Minute, dingtalk.RateLimitFailFast)
+	pool := dingtalk.NewRobotPool(dingtalk.PoolQuotaAware, a, b)
+
+	for i := 0; i < 2; i++ {
+		if err := pool.SendText("TEST: Pool"); err != nil {
+			t.Fatalf("SendText() error = %v", err)
+		}
+	}
+	if a.RateLimitRemaining() != 0 || b.RateLimitRemaining() != 0 {
+		t.Errorf("RateLimitRemaining() = %d, %d, want 0, 0", a.RateLimitRemaining(), b.RateLimitRemaining())
+	}
+	if err := pool.SendText("TEST: Pool"); !dingtalk.IsRateLimited(err) {
+		t.Errorf("SendText() error = %v, want rate limited", err)
+	}
+}
+
+func TestRobotPool_Failover(t *testing.T) {
+	var tokens, contents []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg dingtalk.Message
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		token := r.URL.Query().Get("access_token")
+		tokens = append(tokens, token)
+		contents = append(contents, msg.Text.Content)
+		if token == "limited" {
+			_, _ = w.Write([]byte(`{"errcode":410100,"errmsg":"send too fast, exceed 20 times per minute"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer srv.Close()
+
+	prefix := func(next dingtalk.Sender) dingtalk.Sender {
+		return dingtalk.SenderFunc(func(ctx context.Context, msg *dingtalk.Message) error {
+			msg.Text.Content = "[prod] " + msg.Text.Content
+			return next.Send(ctx, msg)
+		})
+	}
+	webhook := srv.URL + "/robot/send?access_token="
+	pool := dingtalk.NewRobotPool(dingtalk.PoolLeastRecentlyUsed,
+		dingtalk.NewRobotCustom().SetWebhook(webhook+"limited").Use(prefix),
+		dingtalk.NewRobotCustom().SetWebhook(webhook+"a").Use(prefix),
+		dingtalk.NewRobotCustom().SetWebhook(webhook+"b").Use(prefix),
+	)
+
+	for i := 0; i < 2; i++ {
+		if err := pool.SendText("hi"); err != nil {
+			t.Fatalf("SendText() error = %v", err)
+		}
+	}
+
+	// 切换后的机器人收到未被累积改写的消息，且记为最近使用
+	want := []string{"limited", "a", "b"}
+	if strings.Join(tokens, ",") != strings.Join(want, ",") {
+		t.Errorf("tokens = %v, want %v", tokens, want)
+	}
+	for _, c := range contents {
+		if c != "[prod] hi" {
+			t.Errorf("contents = %q, want all %q", contents, "[prod] hi")
+			break
+		}
+	}
+}
+
+func TestRobotPool_AutoSplit(t *testing.T) {
+	srvA, srvB := dingtalktest.NewServer(""), dingtalktest.NewServer("")
+	defer srvA.Close()
+	defer srvB.Close()
+	srvA.SetRateLimit(1, time.Minute)
+
+	pool := dingtalk.NewRobotPool(dingtalk.PoolRoundRobin,
+		dingtalk.NewRobotCustom().SetWebhook(srvA.Webhook()).SetAutoSplit(64),
+		dingtalk.NewRobotCustom().SetWebhook(srvB.Webhook()).SetAutoSplit(64),
+	)
+	if err := pool.SendText(strings.Repeat("line of a long stack trace\n", 6)); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+
+	// 第一个机器人发送第2条时被限流，切换后从第2条继续发送
+	a, b := sentMessages(t, srvA), sentMessages(t, srvB)
+	if len(a) != 1 || !strings.Contains(a[0].Text.Content, "(1/") {
+		t.Fatalf("robot A sent %d messages, want part 1 only", len(a))
+	}
+	if len(b) < 2 || !strings.Contains(b[0].Text.Content, "(2/") {
+		t.Fatalf("robot B sent %d messages, want from part 2", len(b))
+	}
+	if total := fmt.Sprintf("(%d/%d)", len(a)+len(b), len(a)+len(b)); !strings.Contains(b[len(b)-1].Text.Content, total) {
+		t.Errorf("last message = %q, want suffix %s", b[len(b)-1].Text.Content, total)
+	}
+}
@@ -0,0 +1,130 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy 发送失败时的重试策略
+type RetryPolicy struct {
+	MaxAttempts    int              // 最大尝试次数(含首次)，小于等于1时不重试
+	InitialBackoff time.Duration    // 首次重试前的等待时间，默认200ms
+	MaxBackoff     time.Duration    // 最长等待时间，默认10s
+	Multiplier     float64          // 等待时间的增长倍数，默认2
+	Jitter         float64          // 等待时间的随机抖动比例(0~1)，默认0.2
+	Retryable      func(error) bool // 错误是否可重试，默认 IsTemporary
+
+	// OnRetry (可选)重试前的回调，attempt为已尝试次数，wait为本次等待时间
+	OnRetry func(attempt int, err error, wait time.Duration)
+
+	// 重试前记录日志，ctx为本次尝试的上下文
+	logRetry func(ctx context.Context, attempt int, err error, wait time.Duration)
+}
+
+// 当前尝试次数的上下文键
+type attemptKey struct{}
+
+// 当前尝试次数，从1开始
+func attemptFromContext(ctx context.Context) int {
+	if n, ok := ctx.Value(attemptKey{}).(int); ok {
+		return n
+	}
+	return 1
+}
+
+// DefaultRetryPolicy 默认重试策略: 最多尝试3次
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// IsTemporary 是否为可重试的临时错误
+//
+// 网络错误、超时、HTTP 5xx及群机器人限流错误码为临时错误；
+// 签名错误、关键词不匹配等为永久错误
+func IsTemporary(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrQuotaExceeded) {
+		return false
+	}
+
+	var re *RobotError
+	if errors.As(err, &re) {
+		return re.StatusCode >= http.StatusInternalServerError || errors.Is(re, ErrRateLimited)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var oe *net.OpError
+	if errors.As(err, &oe) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// 第attempt次重试前的等待时间
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	initial, max, multiplier, jitter := p.InitialBackoff, p.MaxBackoff, p.Multiplier, p.Jitter
+	if initial <= 0 {
+		initial = 200 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 10 * time.Second
+	}
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	if jitter < 0 || jitter > 1 {
+		jitter = 0.2
+	}
+
+	d := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if d > float64(max) {
+		d = float64(max)
+	}
+	d *= 1 + jitter*(rand.Float64()*2-1)
+	return time.Duration(d)
+}
+
+// 按策略执行fn，直至成功、不可重试或达到最大尝试次数
+func (p RetryPolicy) do(ctx context.Context, fn func(ctx context.Context) error) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsTemporary
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptCtx := context.WithValue(ctx, attemptKey{}, attempt)
+		err := fn(attemptCtx)
+		if err == nil || attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		wait := p.backoff(attempt)
+		if p.logRetry != nil {
+			p.logRetry(attemptCtx, attempt, err, wait)
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, wait)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
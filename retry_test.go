@@ -0,0 +1,60 @@
+package dingtalk_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shockerli/dingtalk"
+)
+
+func TestRobotCustom_SetRetryPolicy(t *testing.T) {
+	var timestamps []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timestamps = append(timestamps, r.URL.Query().Get("timestamp"))
+		if len(timestamps) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer srv.Close()
+
+	policy := dingtalk.DefaultRetryPolicy()
+	policy.InitialBackoff = 5 * time.Millisecond
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook(srv.URL + "/robot/send?access_token=test").
+		SetSecret("SECtest").
+		SetRetryPolicy(policy)
+
+	if err := rc.SendText("TEST: Retry"); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+	if len(timestamps) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(timestamps))
+	}
+	if timestamps[0] == timestamps[2] {
+		t.Errorf("retry not re-signed, timestamps = %v", timestamps)
+	}
+}
+
+func TestRobotCustom_SetRetryPolicy_Permanent(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		_, _ = w.Write([]byte(`{"errcode":310000,"errmsg":"sign not match"}`))
+	}))
+	defer srv.Close()
+
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook(srv.URL + "/robot/send?access_token=test").
+		SetRetryPolicy(dingtalk.DefaultRetryPolicy())
+
+	if err := rc.SendText("TEST: Retry"); !dingtalk.IsSignatureInvalid(err) {
+		t.Errorf("SendText() error = %v, want signature invalid", err)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+}
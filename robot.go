@@ -1,14 +1,19 @@
 package dingtalk
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -16,8 +21,29 @@ import (
 //
 // 官方文档: https://developers.dingtalk.com/document/app/custom-robot-access
 type RobotCustom struct {
+	name    string // (可选)机器人名称，用于指标等
 	webhook string // 例: https://oapi.dingtalk.com/robot/send?access_token=xxx
 	secret  string // (可选)例: SEC8a9fc6f36f447d7c497f8c8e08accde4c49b4b5a366fa3903f47e250d6746979
+
+	client    *http.Client      // (可选)自定义HTTP客户端，优先级最高
+	transport http.RoundTripper // (可选)自定义Transport
+	proxy     *url.URL          // (可选)代理地址
+	tlsConfig *tls.Config       // (可选)TLS配置
+	timeout   time.Duration     // (可选)请求超时时间，默认2秒
+	built     *http.Client      // 根据transport/proxy/tlsConfig生成的客户端
+
+	limiter *RateLimiter    // (可选)发送频率限制
+	retry   *RetryPolicy    // (可选)重试策略
+	outbox  Outbox          // (可选)待发送消息的持久化存储
+	breaker *CircuitBreaker // (可选)熔断器
+
+	noValidate     bool         // 发送前不校验消息
+	splitBytes     int          // (可选)超过该长度的Text/Markdown消息自动拆分
+	injectMentions bool         // 自动补全消息内容中的@人标记
+	lenientOptions bool         // 配置项不适用时仅记录警告日志
+	logger         Logger       // (可选)日志
+	metrics        Metrics      // (可选)指标采集
+	middlewares    []Middleware // 自定义中间件
 }
 
 // NewRobotCustom 实例化
@@ -25,6 +51,12 @@ func NewRobotCustom() *RobotCustom {
 	return &RobotCustom{}
 }
 
+// SetName 设置机器人名称，用于指标等
+func (rc *RobotCustom) SetName(name string) *RobotCustom {
+	rc.name = name
+	return rc
+}
+
 // SetWebhook 设置Token
 func (rc *RobotCustom) SetWebhook(t string) *RobotCustom {
 	rc.webhook = t
@@ -37,6 +69,282 @@ func (rc *RobotCustom) SetSecret(s string) *RobotCustom {
 	return rc
 }
 
+// SetHTTPClient 设置自定义HTTP客户端
+//
+// 设置后 SetTransport/SetProxy/SetTLSConfig 均不再生效
+func (rc *RobotCustom) SetHTTPClient(c *http.Client) *RobotCustom {
+	rc.client = c
+	return rc
+}
+
+// SetTransport 设置自定义Transport
+func (rc *RobotCustom) SetTransport(rt http.RoundTripper) *RobotCustom {
+	rc.transport = rt
+	rc.buildClient()
+	return rc
+}
+
+// SetProxy 设置代理地址
+//
+// 仅当未设置Transport或Transport为*http.Transport时生效
+//
+// 示例:
+// 	u, _ := url.Parse("http://proxy.example.com:3128")
+// 	robot.SetProxy(u)
+func (rc *RobotCustom) SetProxy(u *url.URL) *RobotCustom {
+	rc.proxy = u
+	rc.buildClient()
+	return rc
+}
+
+// SetTLSConfig 设置TLS配置
+//
+// 仅当未设置Transport或Transport为*http.Transport时生效
+func (rc *RobotCustom) SetTLSConfig(c *tls.Config) *RobotCustom {
+	rc.tlsConfig = c
+	rc.buildClient()
+	return rc
+}
+
+// SetTimeout 设置请求超时时间(小于等于0时使用默认值2秒)
+func (rc *RobotCustom) SetTimeout(d time.Duration) *RobotCustom {
+	rc.timeout = d
+	return rc
+}
+
+// SetRateLimit 设置发送频率限制，窗口window内最多发送limit条消息
+//
+// 示例:
+// 	robot.SetRateLimit(dingtalk.DefaultRateLimit, dingtalk.DefaultRateLimitWindow, dingtalk.RateLimitBlock)
+func (rc *RobotCustom) SetRateLimit(limit int, window time.Duration, mode RateLimitMode) *RobotCustom {
+	rc.limiter = NewRateLimiter(limit, window, mode)
+	return rc
+}
+
+// SetRateLimiter 设置发送频率限制器，可在同一Webhook的多个实例间共享
+func (rc *RobotCustom) SetRateLimiter(l *RateLimiter) *RobotCustom {
+	rc.limiter = l
+	return rc
+}
+
+// RateLimitRemaining 当前窗口内剩余可发送数量，未设置频率限制时返回-1
+func (rc *RobotCustom) RateLimitRemaining() int {
+	if rc.limiter == nil {
+		return -1
+	}
+	return rc.limiter.Remaining()
+}
+
+// SetRetryPolicy 设置发送失败时的重试策略，每次重试均重新签名
+//
+// 示例:
+// 	robot.SetRetryPolicy(dingtalk.DefaultRetryPolicy())
+func (rc *RobotCustom) SetRetryPolicy(p RetryPolicy) *RobotCustom {
+	rc.retry = &p
+	return rc
+}
+
+// Use 添加自定义中间件，先添加的位于外层
+//
+// 内置的日志、指标采集、熔断、重试、频率限制均位于自定义中间件内层
+//
+// 示例:
+// 	robot.Use(func(next dingtalk.Sender) dingtalk.Sender {
+// 		return dingtalk.SenderFunc(func(ctx context.Context, msg *dingtalk.Message) error {
+// 			err := next.Send(ctx, msg)
+// 			log.Println(msg.MsgType, err)
+// 			return err
+// 		})
+// 	})
+func (rc *RobotCustom) Use(mws ...Middleware) *RobotCustom {
+	rc.middlewares = append(rc.middlewares, mws...)
+	return rc
+}
+
+// SetValidation 设置发送前是否校验消息，默认校验
+func (rc *RobotCustom) SetValidation(enabled bool) *RobotCustom {
+	rc.noValidate = !enabled
+	return rc
+}
+
+// SetAutoSplit 设置超长的Text/Markdown消息自动拆分为多条，n为每条消息内容的最大字节数
+//
+// 按行、Markdown段落及代码块拆分，不会拆开多字节字符，代码块拆分后各自补全起止标记；
+// 拆分后的消息依次编号(如"(1/3)")并受频率限制约束，@人的设置仅保留在最后一条。
+// n<=0时关闭，n的取值范围为64~20000字节
+//
+// 示例:
+// 	robot.SetAutoSplit(4000)
+// 	err := robot.SendText(stackTrace)
+func (rc *RobotCustom) SetAutoSplit(n int) *RobotCustom {
+	switch {
+	case n <= 0:
+		n = 0
+	case n < minSplitBytes:
+		n = minSplitBytes
+	case n > maxContentBytes:
+		n = maxContentBytes
+	}
+	rc.splitBytes = n
+	return rc
+}
+
+// SetMentionInjection 设置是否自动补全消息内容中的@人标记
+//
+// Markdown消息仅当内容中包含"@手机号"或"@用户ID"时才会提醒被@人。
+// 开启后，通过 AtMobiles/AtUserIDs 设置的被@人若未出现在Text/Markdown消息内容中，
+// 其标记将追加到内容末尾，内容中包含 MentionMarker 时替换该占位符
+//
+// 示例:
+// 	robot.SetMentionInjection(true)
+// 	robot.SendMarkdown("TEST: Markdown", "### 服务告警", robot.AtMobiles("19900001111"))
+func (rc *RobotCustom) SetMentionInjection(enabled bool) *RobotCustom {
+	rc.injectMentions = enabled
+	return rc
+}
+
+// SetLenientOptions 设置配置项不适用于当前消息类型时的处理方式
+//
+// 默认返回 ErrOptionNotApplicable 错误；宽松模式下忽略该配置项，并通过日志记录警告
+func (rc *RobotCustom) SetLenientOptions(lenient bool) *RobotCustom {
+	rc.lenientOptions = lenient
+	return rc
+}
+
+// SetLogger 设置日志，记录每次请求、重试及发送结果，URL中的access_token、sign等参数均已脱敏
+//
+// 示例:
+// 	robot.SetLogger(dingtalk.NewSlogLogger(slog.Default()))
+func (rc *RobotCustom) SetLogger(l Logger) *RobotCustom {
+	rc.logger = l
+	return rc
+}
+
+// SetMetrics 设置指标采集，按机器人名称、消息类型、发送结果及错误码记录发送次数和耗时
+//
+// 示例:
+// 	pm := dingtalk.NewPrometheusMetrics()
+// 	robot.SetName("alert").SetMetrics(pm)
+// 	http.Handle("/metrics", pm)
+func (rc *RobotCustom) SetMetrics(m Metrics) *RobotCustom {
+	rc.metrics = m
+	return rc
+}
+
+// SetCircuitBreaker 设置熔断器，熔断中的发送直接返回 ErrCircuitOpen
+//
+// 示例:
+// 	robot.SetCircuitBreaker(dingtalk.NewCircuitBreaker(dingtalk.CircuitBreakerConfig{}))
+func (rc *RobotCustom) SetCircuitBreaker(cb *CircuitBreaker) *RobotCustom {
+	rc.breaker = cb
+	return rc
+}
+
+// CircuitState 熔断器当前状态，未设置熔断器时返回 CircuitClosed
+func (rc *RobotCustom) CircuitState() CircuitState {
+	if rc.breaker == nil {
+		return CircuitClosed
+	}
+	return rc.breaker.State()
+}
+
+// SetOutbox 设置待发送消息的持久化存储
+//
+// 消息发送前写入Outbox，发送成功后确认；进程重启后通过 ReplayOutbox 重新发送未确认的消息
+//
+// 示例:
+// 	ob, err := dingtalk.OpenFileOutbox("/var/lib/app/dingtalk.outbox")
+// 	robot.SetOutbox(ob)
+// 	err = robot.ReplayOutbox(ctx)
+func (rc *RobotCustom) SetOutbox(ob Outbox) *RobotCustom {
+	rc.outbox = ob
+	return rc
+}
+
+// ReplayOutbox 按写入顺序重新发送Outbox中未确认的消息
+//
+// 遇到网络错误、服务端限流等临时错误时停止，消息保留至下次重新发送；
+// 无法解析或被接口拒绝(如关键词不匹配、签名错误)的消息确认后跳过，处理完所有消息后返回首个此类错误
+func (rc *RobotCustom) ReplayOutbox(ctx context.Context) error {
+	if rc.outbox == nil {
+		return nil
+	}
+
+	entries, err := rc.outbox.Pending()
+	if err != nil {
+		return err
+	}
+
+	var rejected error
+	for _, entry := range entries {
+		var msg Message
+		if err = json.Unmarshal(entry.Payload, &msg); err == nil {
+			if err = rc.chain().Send(ctx, &msg); errors.Is(err, errDropped) {
+				return nil
+			} else if err != nil && !isRejected(err) {
+				return err
+			}
+		}
+		if err != nil {
+			rc.log(ctx, LogWarn, "dingtalk: outbox entry discarded", "id", entry.ID, "error", logError(err))
+			if rejected == nil {
+				rejected = err
+			}
+		}
+		if err = rc.outbox.Ack(entry.ID); err != nil {
+			return err
+		}
+	}
+	return rejected
+}
+
+// 根据transport/proxy/tlsConfig生成客户端
+func (rc *RobotCustom) buildClient() {
+	rt := rc.transport
+	if rc.proxy != nil || rc.tlsConfig != nil {
+		var t *http.Transport
+		switch v := rt.(type) {
+		case nil:
+			t = newTransport()
+		case *http.Transport:
+			t = v.Clone()
+		}
+		if t != nil {
+			if rc.proxy != nil {
+				t.Proxy = http.ProxyURL(rc.proxy)
+			}
+			if rc.tlsConfig != nil {
+				t.TLSClientConfig = rc.tlsConfig
+			}
+			rt = t
+		}
+	}
+
+	rc.built = nil
+	if rt != nil {
+		rc.built = &http.Client{Transport: rt}
+	}
+}
+
+// 当前使用的HTTP客户端
+func (rc *RobotCustom) getClient() *http.Client {
+	if rc.client != nil {
+		return rc.client
+	}
+	if rc.built != nil {
+		return rc.built
+	}
+	return httpClient
+}
+
+// 当前使用的超时时间
+func (rc *RobotCustom) getTimeout() time.Duration {
+	if rc.timeout > 0 {
+		return rc.timeout
+	}
+	return defaultTimeout
+}
+
 // SendText 发送Text消息
 //
 // 示例:
@@ -44,12 +352,15 @@ func (rc *RobotCustom) SetSecret(s string) *RobotCustom {
 // 	robot.SendText("TEST: Text&AtAll", robot.AtAll())
 // 	robot.SendText("TEST: Text&AtMobiles", robot.AtMobiles("19900001111"))
 func (rc *RobotCustom) SendText(content string, opts ...RobotOption) error {
-	msg := &robotMsg{
-		MsgType: msgTypeText,
-		Text:    &robotText{Content: content},
-	}
+	return rc.SendTextContext(context.Background(), content, opts...)
+}
 
-	return rc.send(msg, opts...)
+// SendTextContext 发送Text消息，请求受ctx的超时及取消控制
+//
+// 示例:
+// 	robot.SendTextContext(ctx, "TEST: Text")
+func (rc *RobotCustom) SendTextContext(ctx context.Context, content string, opts ...RobotOption) error {
+	return rc.send(ctx, NewTextMessage(content), opts...)
 }
 
 // SendLink 发送Link消息
@@ -62,17 +373,12 @@ func (rc *RobotCustom) SendText(content string, opts ...RobotOption) error {
 //		"https://www.wangbase.com/blogimg/asset/202101/bg2021011601.jpg",
 //	)
 func (rc *RobotCustom) SendLink(title, text, msgURL, picURL string, opts ...RobotOption) error {
-	msg := &robotMsg{
-		MsgType: msgTypeLink,
-		Link: &robotLink{
-			Title:      title,
-			Text:       text,
-			MessageURL: msgURL,
-			PicURL:     picURL,
-		},
-	}
+	return rc.SendLinkContext(context.Background(), title, text, msgURL, picURL, opts...)
+}
 
-	return rc.send(msg, opts...)
+// SendLinkContext 发送Link消息，请求受ctx的超时及取消控制
+func (rc *RobotCustom) SendLinkContext(ctx context.Context, title, text, msgURL, picURL string, opts ...RobotOption) error {
+	return rc.send(ctx, NewLinkMessage(title, text, msgURL, picURL), opts...)
 }
 
 // SendMarkdown 发送Markdown消息
@@ -82,15 +388,12 @@ func (rc *RobotCustom) SendLink(title, text, msgURL, picURL string, opts ...Robo
 // 	robot.SendMarkdown("TEST: Markdown&AtAll", markdown, robot.AtAll())
 // 	robot.SendMarkdown("TEST: Markdown&AtMobiles", markdown, robot.AtMobiles("19900001111"))
 func (rc *RobotCustom) SendMarkdown(title, text string, opts ...RobotOption) error {
-	msg := &robotMsg{
-		MsgType: msgTypeMarkdown,
-		Markdown: &robotMarkdown{
-			Title: title,
-			Text:  text,
-		},
-	}
+	return rc.SendMarkdownContext(context.Background(), title, text, opts...)
+}
 
-	return rc.send(msg, opts...)
+// SendMarkdownContext 发送Markdown消息，请求受ctx的超时及取消控制
+func (rc *RobotCustom) SendMarkdownContext(ctx context.Context, title, text string, opts ...RobotOption) error {
+	return rc.send(ctx, NewMarkdownMessage(title, text), opts...)
 }
 
 // SendActionCard 发送ActionCard消息
@@ -102,17 +405,12 @@ func (rc *RobotCustom) SendMarkdown(title, text string, opts ...RobotOption) err
 //		robot.SingleCard("阅读全文", "https://github.com/shockerli"),
 //	)
 func (rc *RobotCustom) SendActionCard(title, text string, opts ...RobotOption) error {
-	msg := &robotMsg{
-		MsgType: msgTypeActionCard,
-		ActionCard: &robotActionCard{
-			Title:          title,
-			Text:           text,
-			HideAvatar:     "0", // 默认展示
-			BtnOrientation: "1", // 默认横向排列
-		},
-	}
+	return rc.SendActionCardContext(context.Background(), title, text, opts...)
+}
 
-	return rc.send(msg, opts...)
+// SendActionCardContext 发送ActionCard消息，请求受ctx的超时及取消控制
+func (rc *RobotCustom) SendActionCardContext(ctx context.Context, title, text string, opts ...RobotOption) error {
+	return rc.send(ctx, NewActionCardMessage(title, text), opts...)
 }
 
 // SendFeedCard 发送FeedCard消息
@@ -123,24 +421,157 @@ func (rc *RobotCustom) SendActionCard(title, text string, opts ...RobotOption) e
 //		robot.FeedCard("考古学家在英国发现两枚11世纪北宋时期的中国硬币", "https://www.caitlingreen.org/2020/12/another-medieval-chinese-coin-from-england.html", "https://www.wangbase.com/blogimg/asset/202101/bg2021012208.jpg"),
 //	)
 func (rc *RobotCustom) SendFeedCard(opts ...RobotOption) error {
-	msg := &robotMsg{
-		MsgType: msgTypeFeedCard,
-		FeedCard: &robotFeedCard{
-			Links: []robotFeedCardLink{},
-		},
-	}
+	return rc.SendFeedCardContext(context.Background(), opts...)
+}
+
+// SendFeedCardContext 发送FeedCard消息，请求受ctx的超时及取消控制
+func (rc *RobotCustom) SendFeedCardContext(ctx context.Context, opts ...RobotOption) error {
+	return rc.send(ctx, NewFeedCardMessage(), opts...)
+}
 
-	return rc.send(msg, opts...)
+// Send 发送已构造的消息，msg本身不会被修改
+//
+// 示例:
+// 	msg := dingtalk.NewTextMessage("TEST: Text").With(robot.AtAll())
+// 	robot.Send(ctx, msg)
+func (rc *RobotCustom) Send(ctx context.Context, msg *Message) error {
+	return rc.deliver(ctx, msg.clone())
 }
 
 // 发送消息
-func (rc *RobotCustom) send(msg *robotMsg, opts ...RobotOption) error {
-	// options
-	for _, opt := range opts {
-		opt(msg)
+func (rc *RobotCustom) send(ctx context.Context, msg *Message, opts ...RobotOption) error {
+	return rc.deliver(ctx, msg.With(opts...))
+}
+
+// 发送已完成配置的消息
+func (rc *RobotCustom) deliver(ctx context.Context, msg *Message) error {
+	return rc.deliverFrom(ctx, msg, 0)
+}
+
+// 发送已完成配置的消息，拆分后从第from条(从0开始)开始发送
+//
+// 拆分后的某条消息发送失败时返回 *partError，记录失败的位置
+func (rc *RobotCustom) deliverFrom(ctx context.Context, msg *Message, from int) error {
+	if err := rc.checkOptions(ctx, msg); err != nil {
+		return err
+	}
+	if rc.injectMentions {
+		msg = injectMentions(msg)
+	}
+	if rc.splitBytes <= 0 {
+		return rc.deliverOne(ctx, msg)
+	}
+
+	parts := splitMessage(msg, rc.splitBytes)
+	for i := from; i < len(parts); i++ {
+		if err := rc.deliverOne(ctx, parts[i]); err != nil {
+			if len(parts) == 1 {
+				return err
+			}
+			return &partError{index: i, total: len(parts), err: err}
+		}
+	}
+	return nil
+}
+
+// 发送单条消息
+func (rc *RobotCustom) deliverOne(ctx context.Context, msg *Message) error {
+	if !rc.noValidate {
+		if err := msg.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// 发送前写入Outbox，临时会话消息不持久化
+	var id string
+	if rc.outbox != nil && msg.outgoing.SessionWebhook == "" {
+		v, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		if id, err = rc.outbox.Put(v); err != nil {
+			return err
+		}
+	}
+
+	if err := rc.chain().Send(ctx, msg); err != nil {
+		// 本地限流丢弃的消息不返回错误，Outbox中的消息保留至重新发送
+		if errors.Is(err, errDropped) {
+			return nil
+		}
+		if id != "" {
+			rc.settleOutbox(ctx, id, err)
+		}
+		return err
 	}
+	if id != "" {
+		return rc.outbox.Ack(id)
+	}
+	return nil
+}
 
-	v, err := json.Marshal(msg)
+// 处理发送失败的Outbox消息
+//
+// 被接口拒绝(重试也无法成功)的消息直接确认，避免阻塞重新发送；
+// 其余消息保留，机器人池中由其他机器人发送成功后再确认
+func (rc *RobotCustom) settleOutbox(ctx context.Context, id string, err error) {
+	if isRejected(err) {
+		rc.log(ctx, LogWarn, "dingtalk: outbox entry discarded", "id", id, "error", logError(err))
+		if aerr := rc.outbox.Ack(id); aerr != nil {
+			rc.log(ctx, LogError, "dingtalk: outbox ack failed", "id", id, "error", logError(aerr))
+		}
+		return
+	}
+	if acks := outboxAcksFromContext(ctx); acks != nil {
+		acks.add(rc.outbox, id)
+	}
+}
+
+// 检查配置项错误，宽松模式下仅记录警告日志
+func (rc *RobotCustom) checkOptions(ctx context.Context, msg *Message) error {
+	err := msg.Err()
+	if err != nil && rc.lenientOptions {
+		rc.log(ctx, LogWarn, "dingtalk: option ignored", "msgtype", msg.MsgType, "error", err)
+		return nil
+	}
+	return err
+}
+
+// 组装中间件链
+//
+// 由外到内依次为: 自定义中间件、日志、指标采集、熔断、重试、频率限制
+func (rc *RobotCustom) chain() Sender {
+	mws := make([]Middleware, 0, len(rc.middlewares)+5)
+	mws = append(mws, rc.middlewares...)
+	if rc.logger != nil {
+		mws = append(mws, LoggingMiddleware(rc.robotName(), rc.logger))
+	}
+	if rc.metrics != nil {
+		mws = append(mws, MetricsMiddleware(rc.robotName(), rc.metrics))
+	}
+	if rc.breaker != nil {
+		mws = append(mws, CircuitBreakerMiddleware(rc.breaker))
+	}
+	if rc.retry != nil {
+		policy := *rc.retry
+		if rc.logger != nil {
+			policy.logRetry = func(ctx context.Context, attempt int, err error, wait time.Duration) {
+				rc.logger.Log(ctx, LogWarn, "dingtalk: retrying",
+					"robot", rc.robotName(), "attempt", attempt, "wait", wait, "error", logError(err))
+			}
+		}
+		mws = append(mws, RetryMiddleware(policy))
+	}
+	if rc.limiter != nil {
+		mws = append(mws, RateLimitMiddleware(rc.limiter))
+	}
+
+	return Chain(SenderFunc(rc.post), mws...)
+}
+
+// 签名并请求接口
+func (rc *RobotCustom) post(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(msg)
 	if err != nil {
 		return err
 	}
@@ -160,24 +591,37 @@ func (rc *RobotCustom) send(msg *robotMsg, opts ...RobotOption) error {
 	}
 
 	// 请求接口
-	data, err := request(api, v)
+	start := time.Now()
+	rc.log(ctx, LogDebug, "dingtalk: request", "url", redactURL(api), "attempt", attemptFromContext(ctx))
+	data, resp, err := request(ctx, rc.getClient(), rc.getTimeout(), api, body)
 	if err != nil {
+		rc.log(ctx, LogDebug, "dingtalk: request failed", "duration", time.Since(start), "error", logError(err))
 		return err
 	}
 
-	var response struct {
-		ErrCode int    `json:"errcode"`
-		ErrMsg  string `json:"errmsg"`
+	err = parseResponse(resp, data)
+	var errCode int
+	var re *RobotError
+	if errors.As(err, &re) {
+		errCode = re.ErrCode
 	}
-	err = json.Unmarshal(data, &response)
-	if err != nil {
-		return err
-	}
-	if response.ErrCode != 0 {
-		return fmt.Errorf("群机器人消息发送失败: %v", response.ErrMsg)
+	rc.log(ctx, LogDebug, "dingtalk: response", "status", resp.StatusCode, "errcode", errCode, "duration", time.Since(start))
+	return err
+}
+
+// 机器人名称，未设置时为default
+func (rc *RobotCustom) robotName() string {
+	if rc.name == "" {
+		return "default"
 	}
+	return rc.name
+}
 
-	return nil
+// 记录日志
+func (rc *RobotCustom) log(ctx context.Context, level LogLevel, msg string, kv ...interface{}) {
+	if rc.logger != nil {
+		rc.logger.Log(ctx, level, msg, append([]interface{}{"robot", rc.robotName()}, kv...)...)
+	}
 }
 
 // 签名算法
@@ -187,88 +631,19 @@ func (*RobotCustom) sign(ts int64, secret string) string {
 	return base64.StdEncoding.EncodeToString(h.Sum(nil))
 }
 
-// robot message definition
-
-// 机器人消息类型
-const (
-	msgTypeText       = "text"
-	msgTypeLink       = "link"
-	msgTypeMarkdown   = "markdown"
-	msgTypeActionCard = "actionCard"
-	msgTypeFeedCard   = "feedCard"
-)
-
-// 机器人消息结构
-type robotMsg struct {
-	MsgType    string           `json:"msgtype"` // 消息类型
-	At         *robotAt         `json:"at,omitempty"`
-	Text       *robotText       `json:"text,omitempty"`
-	Link       *robotLink       `json:"link,omitempty"`
-	Markdown   *robotMarkdown   `json:"markdown,omitempty"`
-	ActionCard *robotActionCard `json:"actionCard,omitempty"`
-	FeedCard   *robotFeedCard   `json:"feedCard,omitempty"`
-	outgoing   RobotOutgoing
-}
-
-// 消息@人的设置
-// [NOTICE] 仅针对Text/Link/Markdown类型有效
-type robotAt struct {
-	AtMobiles []string `json:"atMobiles,omitempty"` // 被@人的手机号
-	IsAtAll   bool     `json:"isAtAll,omitempty"`   // 是否@所有人
-}
-
-// 消息类型: Text
-type robotText struct {
-	Content string `json:"content"` // 消息内容
-}
-
-// 消息类型: Link
-type robotLink struct {
-	Title      string `json:"title"`            // 消息标题
-	Text       string `json:"text"`             // 消息内容，如果太长只会部分展示
-	MessageURL string `json:"messageUrl"`       // 点击消息跳转的UR
-	PicURL     string `json:"picUrl,omitempty"` // 图片URL
-}
-
-// 消息类型: Markdown
-type robotMarkdown struct {
-	Title string `json:"title"` // 首屏会话透出的展示内容
-	Text  string `json:"text"`  // Markdown格式的消息
-}
-
-// 消息类型: ActionCard
-// * 整体跳转
-// * 独立跳转
-// [NOTICE]设置singleTitle和singleURL后，btns无效
-type robotActionCard struct {
-	Title          string               `json:"title"`                    // 首屏会话透出的展示内容
-	Text           string               `json:"text"`                     // Markdown格式的消息
-	SingleTitle    string               `json:"singleTitle,omitempty"`    // 单个按钮的标题
-	SingleURL      string               `json:"singleURL,omitempty"`      // 点击singleTitle按钮触发的URL
-	HideAvatar     string               `json:"hideAvatar,omitempty"`     // 0：显示图片，1：隐藏图片
-	BtnOrientation string               `json:"btnOrientation,omitempty"` // 0：按钮竖直排列，1：按钮横向排列
-	Btns           []robotActionCardBtn `json:"btns,omitempty"`           // 独立跳转的按钮列表
-}
+// RobotOption 群机器人-消息配置项
+//
+// 配置项不适用于当前消息类型时返回错误
+type RobotOption func(*Message) error
 
-type robotActionCardBtn struct {
-	Title     string `json:"title"`     // 按钮标题
-	ActionURL string `json:"actionURL"` // 点击按钮触发的URL
-}
+// ErrOptionNotApplicable 消息配置项不适用于当前消息类型
+var ErrOptionNotApplicable = errors.New("消息配置项不适用于当前消息类型")
 
-// 消息类型: FeedCard
-type robotFeedCard struct {
-	Links []robotFeedCardLink `json:"links"`
+// 配置项不适用的错误
+func optionError(name, msgType string, types ...string) error {
+	return fmt.Errorf("%w: %s 仅适用于%s消息，当前为%s消息", ErrOptionNotApplicable, name, strings.Join(types, "/"), msgType)
 }
 
-type robotFeedCardLink struct {
-	Title      string `json:"title"`
-	MessageURL string `json:"messageURL"` // 点击单条信息到跳转链接
-	PicURL     string `json:"picURL"`     // 单条信息后面图片的URL
-}
-
-// RobotOption 群机器人-消息配置项
-type RobotOption func(*robotMsg)
-
 // AtAll 设置是否@所有人
 //
 // 适用Text/Markdown类型
@@ -276,14 +651,15 @@ type RobotOption func(*robotMsg)
 // 示例:
 // 	robot.SendMarkdown("TEST: Markdown&AtAll", markdown, robot.AtAll())
 func (rc *RobotCustom) AtAll() RobotOption {
-	return func(msg *robotMsg) {
-		if msg.MsgType != msgTypeText && msg.MsgType != msgTypeMarkdown {
-			return
+	return func(msg *Message) error {
+		if msg.MsgType != MsgTypeText && msg.MsgType != MsgTypeMarkdown {
+			return optionError("AtAll", msg.MsgType, MsgTypeText, MsgTypeMarkdown)
 		}
 		if msg.At == nil {
-			msg.At = &robotAt{}
+			msg.At = &RobotAt{}
 		}
 		msg.At.IsAtAll = true
+		return nil
 	}
 }
 
@@ -294,14 +670,34 @@ func (rc *RobotCustom) AtAll() RobotOption {
 // 示例:
 // 	robot.SendMarkdown("TEST: Markdown&AtMobiles", markdown, robot.AtMobiles("19900001111"))
 func (rc *RobotCustom) AtMobiles(m ...string) RobotOption {
-	return func(msg *robotMsg) {
-		if msg.MsgType != msgTypeText && msg.MsgType != msgTypeMarkdown {
-			return
+	return func(msg *Message) error {
+		if msg.MsgType != MsgTypeText && msg.MsgType != MsgTypeMarkdown {
+			return optionError("AtMobiles", msg.MsgType, MsgTypeText, MsgTypeMarkdown)
 		}
 		if msg.At == nil {
-			msg.At = &robotAt{}
+			msg.At = &RobotAt{}
 		}
 		msg.At.AtMobiles = m
+		return nil
+	}
+}
+
+// AtUserIDs 设置@人的用户ID，用于未公开手机号的成员
+//
+// 适用Text/Markdown类型
+//
+// 示例:
+// 	robot.SendMarkdown("TEST: Markdown&AtUserIDs", markdown, robot.AtUserIDs("manager1234"))
+func (rc *RobotCustom) AtUserIDs(ids ...string) RobotOption {
+	return func(msg *Message) error {
+		if msg.MsgType != MsgTypeText && msg.MsgType != MsgTypeMarkdown {
+			return optionError("AtUserIDs", msg.MsgType, MsgTypeText, MsgTypeMarkdown)
+		}
+		if msg.At == nil {
+			msg.At = &RobotAt{}
+		}
+		msg.At.AtUserIDs = ids
+		return nil
 	}
 }
 
@@ -317,11 +713,12 @@ func (rc *RobotCustom) AtMobiles(m ...string) RobotOption {
 //		robot.HideAvatar("1"),
 // 	)
 func (rc *RobotCustom) HideAvatar(v string) RobotOption {
-	return func(msg *robotMsg) {
-		if msg.MsgType != msgTypeActionCard {
-			return
+	return func(msg *Message) error {
+		if msg.MsgType != MsgTypeActionCard {
+			return optionError("HideAvatar", msg.MsgType, MsgTypeActionCard)
 		}
 		msg.ActionCard.HideAvatar = v
+		return nil
 	}
 }
 
@@ -338,11 +735,12 @@ func (rc *RobotCustom) HideAvatar(v string) RobotOption {
 //		robot.BtnOrientation("0"),
 //	)
 func (rc *RobotCustom) BtnOrientation(v string) RobotOption {
-	return func(msg *robotMsg) {
-		if msg.MsgType != msgTypeActionCard {
-			return
+	return func(msg *Message) error {
+		if msg.MsgType != MsgTypeActionCard {
+			return optionError("BtnOrientation", msg.MsgType, MsgTypeActionCard)
 		}
 		msg.ActionCard.BtnOrientation = v
+		return nil
 	}
 }
 
@@ -357,12 +755,13 @@ func (rc *RobotCustom) BtnOrientation(v string) RobotOption {
 //		robot.SingleCard("阅读全文", "https://github.com/shockerli"),
 //	)
 func (rc *RobotCustom) SingleCard(title, url string) RobotOption {
-	return func(msg *robotMsg) {
-		if msg.MsgType != msgTypeActionCard {
-			return
+	return func(msg *Message) error {
+		if msg.MsgType != MsgTypeActionCard {
+			return optionError("SingleCard", msg.MsgType, MsgTypeActionCard)
 		}
 		msg.ActionCard.SingleTitle = title
 		msg.ActionCard.SingleURL = url
+		return nil
 	}
 }
 
@@ -378,14 +777,15 @@ func (rc *RobotCustom) SingleCard(title, url string) RobotOption {
 //		robot.MultiCard("不感兴趣", "https://github.com/shockerli"),
 //	)
 func (rc *RobotCustom) MultiCard(title, url string) RobotOption {
-	return func(msg *robotMsg) {
-		if msg.MsgType != msgTypeActionCard {
-			return
+	return func(msg *Message) error {
+		if msg.MsgType != MsgTypeActionCard {
+			return optionError("MultiCard", msg.MsgType, MsgTypeActionCard)
 		}
-		msg.ActionCard.Btns = append(msg.ActionCard.Btns, robotActionCardBtn{
+		msg.ActionCard.Btns = append(msg.ActionCard.Btns, RobotActionCardBtn{
 			Title:     title,
 			ActionURL: url,
 		})
+		return nil
 	}
 }
 
@@ -399,15 +799,16 @@ func (rc *RobotCustom) MultiCard(title, url string) RobotOption {
 //		robot.FeedCard("考古学家在英国发现两枚11世纪北宋时期的中国硬币", "https://www.caitlingreen.org/2020/12/another-medieval-chinese-coin-from-england.html", "https://www.wangbase.com/blogimg/asset/202101/bg2021012208.jpg"),
 //	)
 func (rc *RobotCustom) FeedCard(title, msgURL, picURL string) RobotOption {
-	return func(msg *robotMsg) {
-		if msg.MsgType != msgTypeFeedCard {
-			return
+	return func(msg *Message) error {
+		if msg.MsgType != MsgTypeFeedCard {
+			return optionError("FeedCard", msg.MsgType, MsgTypeFeedCard)
 		}
-		msg.FeedCard.Links = append(msg.FeedCard.Links, robotFeedCardLink{
+		msg.FeedCard.Links = append(msg.FeedCard.Links, RobotFeedCardLink{
 			Title:      title,
 			MessageURL: msgURL,
 			PicURL:     picURL,
 		})
+		return nil
 	}
 }
 
@@ -417,8 +818,9 @@ func (rc *RobotCustom) FeedCard(title, msgURL, picURL string) RobotOption {
 // 	og, err := robot.ParseOutgoing(bytes.NewBufferString(callbackBody))
 //	err = robot.SendText("callback", robot.WithOutgoing(og))
 func (rc *RobotCustom) WithOutgoing(og RobotOutgoing) RobotOption {
-	return func(msg *robotMsg) {
+	return func(msg *Message) error {
 		msg.outgoing = og
+		return nil
 	}
 }
 
@@ -486,5 +888,5 @@ type RobotOutgoing struct {
 	SenderNick                string    `json:"senderNick"`                // 发送者昵称
 	SessionWebhook            string    `json:"sessionWebhook"`            // 临时的发送消息接口
 	SessionWebhookExpiredTime int64     `json:"sessionWebhookExpiredTime"` // SessionWebhook可用的有效截止时间
-	Text                      robotText `json:"text"`                      // Text类型的消息体
+	Text                      RobotText `json:"text"`                      // Text类型的消息体
 }
@@ -2,17 +2,44 @@ package dingtalk_test
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/shockerli/dingtalk"
+	"github.com/shockerli/dingtalk/dingtalktest"
 )
 
 var robot *dingtalk.RobotCustom
+var server *dingtalktest.Server
 
-func init() {
+// 设置环境变量 DINGTALK_WEBHOOK、DINGTALK_SECRET 时请求真实的群机器人，否则请求模拟服务
+func TestMain(m *testing.M) {
 	robot = dingtalk.NewRobotCustom()
-	robot.SetWebhook("your_robot_webhook")
-	robot.SetSecret("your_secret")
+	if webhook := os.Getenv("DINGTALK_WEBHOOK"); webhook != "" {
+		robot.SetWebhook(webhook)
+		robot.SetSecret(os.Getenv("DINGTALK_SECRET"))
+	} else {
+		server = dingtalktest.NewServer("SECtest")
+		robot.SetWebhook(server.Webhook())
+		robot.SetSecret(server.Secret)
+	}
+
+	code := m.Run()
+	if server != nil {
+		server.Close()
+	}
+	os.Exit(code)
 }
 
 func TestRobotCustom_SendText(t *testing.T) {
@@ -30,6 +57,11 @@ func TestRobotCustom_SendText(t *testing.T) {
 	if err := robot.SendText("TEST: Text&AtMobiles", robot.AtMobiles("19900001111")); err != nil {
 		t.Errorf("SendText() && AtMobiles() error = %v", err)
 	}
+
+	// AtUserIDs
+	if err := robot.SendText("TEST: Text&AtUserIDs", robot.AtUserIDs("manager1234")); err != nil {
+		t.Errorf("SendText() && AtUserIDs() error = %v", err)
+	}
 }
 
 func TestRobotCustom_SendLink(t *testing.T) {
@@ -130,8 +162,195 @@ func TestRobotCustom_Outgoing(t *testing.T) {
 	if err != nil {
 		t.Errorf("ParseOutgoing() error = %v", err)
 	}
+	if server != nil {
+		og.SessionWebhook = server.SessionWebhook()
+		og.SessionWebhookExpiredTime = time.Now().Add(time.Hour).UnixNano() / 1e6
+	}
 	err = robot.SendText("callback", robot.WithOutgoing(og))
 	if err != nil {
 		t.Errorf("WithOutgoing() error= %v", err)
 	}
 }
+
+func TestRobotCustom_SendTextContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer srv.Close()
+
+	rc := dingtalk.NewRobotCustom().SetWebhook(srv.URL + "/robot/send?access_token=test")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := rc.SendTextContext(ctx, "TEST: TextContext")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SendTextContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// 始终返回成功的Transport
+func okTransport() http.RoundTripper {
+	return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(`{"errcode":0,"errmsg":"ok"}`)),
+			Header:     make(http.Header),
+		}, nil
+	})
+}
+
+func TestRobotCustom_SetTransport(t *testing.T) {
+	var called bool
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook("https://oapi.dingtalk.com/robot/send?access_token=test").
+		SetTransport(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			called = true
+			return okTransport().RoundTrip(r)
+		}))
+
+	if err := rc.SendText("TEST: Transport"); err != nil {
+		t.Errorf("SendText() error = %v", err)
+	}
+	if !called {
+		t.Errorf("SetTransport() transport not used")
+	}
+}
+
+func TestRobotCustom_SetTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer srv.Close()
+
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook(srv.URL + "/robot/send?access_token=test").
+		SetTimeout(50 * time.Millisecond)
+
+	if err := rc.SendText("TEST: Timeout"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SendText() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+// 模拟代理服务，记录请求的目标地址
+func proxyServer(hosts *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*hosts = append(*hosts, r.URL.Host)
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+}
+
+func TestRobotCustom_SetProxy(t *testing.T) {
+	var hosts []string
+	proxy := proxyServer(&hosts)
+	defer proxy.Close()
+	u, _ := url.Parse(proxy.URL)
+
+	// 未设置Transport时基于默认配置
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook("http://oapi.dingtalk.invalid/robot/send?access_token=test").
+		SetProxy(u)
+	if err := rc.SendText("TEST: Proxy"); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+
+	// 自定义的*http.Transport被复制，原对象不受影响
+	tr := &http.Transport{}
+	rc = dingtalk.NewRobotCustom().
+		SetWebhook("http://oapi.dingtalk.invalid/robot/send?access_token=test").
+		SetTransport(tr).
+		SetProxy(u)
+	if err := rc.SendText("TEST: Proxy"); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+	if tr.Proxy != nil {
+		t.Errorf("SetProxy() modified the custom transport")
+	}
+
+	if want := []string{"oapi.dingtalk.invalid", "oapi.dingtalk.invalid"}; strings.Join(hosts, ",") != strings.Join(want, ",") {
+		t.Errorf("proxied hosts = %v, want %v", hosts, want)
+	}
+}
+
+func TestRobotCustom_SetTLSConfig(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0) // 忽略证书校验失败的握手日志
+	srv.StartTLS()
+	defer srv.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+
+	// 默认配置不信任测试证书
+	rc := dingtalk.NewRobotCustom().SetWebhook(srv.URL + "/robot/send?access_token=test")
+	if err := rc.SendText("TEST: TLS"); err == nil {
+		t.Fatal("SendText() error = nil, want certificate error")
+	}
+
+	tr := &http.Transport{}
+	for _, rc := range []*dingtalk.RobotCustom{
+		dingtalk.NewRobotCustom().SetTLSConfig(&tls.Config{RootCAs: roots}),
+		dingtalk.NewRobotCustom().SetTransport(tr).SetTLSConfig(&tls.Config{RootCAs: roots}),
+	} {
+		if err := rc.SetWebhook(srv.URL + "/robot/send?access_token=test").SendText("TEST: TLS"); err != nil {
+			t.Errorf("SendText() error = %v", err)
+		}
+	}
+	if tr.TLSClientConfig != nil && tr.TLSClientConfig.RootCAs == roots {
+		t.Errorf("SetTLSConfig() modified the custom transport")
+	}
+}
+
+func TestRobotCustom_SetHTTPClient(t *testing.T) {
+	var hosts []string
+	proxy := proxyServer(&hosts)
+	defer proxy.Close()
+	u, _ := url.Parse(proxy.URL)
+
+	// 自定义HTTP客户端优先于Transport、代理及TLS配置
+	var called bool
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook("http://oapi.dingtalk.invalid/robot/send?access_token=test").
+		SetProxy(u).
+		SetTLSConfig(&tls.Config{}).
+		SetHTTPClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			called = true
+			return okTransport().RoundTrip(r)
+		})})
+	if err := rc.SendText("TEST: Client"); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+	if !called || len(hosts) != 0 {
+		t.Errorf("SetHTTPClient() client used = %v, proxied hosts = %v", called, hosts)
+	}
+}
+
+func TestRobotCustom_RedactError(t *testing.T) {
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook("http://127.0.0.1:1/robot/send?access_token=secret_token").
+		SetSecret("SECtest")
+
+	err := rc.SendText("TEST: Redact")
+	if err == nil {
+		t.Fatalf("SendText() error = nil, want error")
+	}
+	if strings.Contains(err.Error(), "secret_token") || !strings.Contains(err.Error(), "sign=REDACTED") {
+		t.Errorf("SendText() error = %v, want redacted url", err)
+	}
+
+	var ue *url.Error
+	if !errors.As(err, &ue) || ue.Unwrap() == nil {
+		t.Errorf("SendText() error = %v, want unwrappable *url.Error", err)
+	}
+}
@@ -0,0 +1,262 @@
+package dingtalk
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// 自动拆分时每条消息的最小长度(字节)
+const minSplitBytes = 64
+
+// 拆分后的某条消息发送失败，之前的消息已发送
+type partError struct {
+	index int // 失败的位置，从0开始
+	total int
+	err   error
+}
+
+func (e *partError) Error() string {
+	return fmt.Sprintf("第%d/%d条消息发送失败: %v", e.index+1, e.total, e.err)
+}
+
+func (e *partError) Unwrap() error {
+	return e.err
+}
+
+// 拆分超长的Text/Markdown消息，依次编号，@人的设置仅保留在最后一条
+func splitMessage(msg *Message, limit int) []*Message {
+	var content string
+	markdown := msg.MsgType == MsgTypeMarkdown
+	switch {
+	case msg.MsgType == MsgTypeText && msg.Text != nil:
+		content = msg.Text.Content
+	case markdown && msg.Markdown != nil:
+		content = msg.Markdown.Text
+	default:
+		return []*Message{msg}
+	}
+	if limit <= 0 || len(content) <= limit {
+		return []*Message{msg}
+	}
+
+	// 按总条数的位数预留编号的长度
+	var chunks []string
+	for total := 10; ; total *= 10 {
+		chunks = splitContent(content, limit-len(partSuffix(markdown, total-1, total-1)), markdown)
+		if len(chunks) < total {
+			break
+		}
+	}
+
+	parts := make([]*Message, 0, len(chunks))
+	for i, chunk := range chunks {
+		part := msg.clone()
+		suffix := partSuffix(markdown, i+1, len(chunks))
+		if markdown {
+			part.Markdown.Title = fmt.Sprintf("%s (%d/%d)", msg.Markdown.Title, i+1, len(chunks))
+			part.Markdown.Text = chunk + suffix
+		} else {
+			part.Text.Content = chunk + suffix
+		}
+		if i < len(chunks)-1 {
+			part.At = nil
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// 拆分后消息的编号
+func partSuffix(markdown bool, i, total int) string {
+	if markdown {
+		return fmt.Sprintf("\n\n(%d/%d)", i, total)
+	}
+	return fmt.Sprintf("\n(%d/%d)", i, total)
+}
+
+// 按行(Markdown按段落及代码块)拆分内容，每段不超过n字节
+func splitContent(s string, n int, markdown bool) []string {
+	var blocks []string
+	if markdown {
+		blocks = markdownBlocks(s)
+	} else {
+		blocks = splitLines(s)
+	}
+
+	var chunks []string
+	var cur strings.Builder
+	flush := func() {
+		if c := strings.Trim(cur.String(), "\n"); c != "" {
+			chunks = append(chunks, c)
+		}
+		cur.Reset()
+	}
+	for _, b := range blocks {
+		if cur.Len()+len(b) <= n {
+			cur.WriteString(b)
+			continue
+		}
+		flush()
+		if len(b) <= n {
+			cur.WriteString(b)
+			continue
+		}
+		// 最后一段可与后续内容合并
+		pieces := splitBlock(b, n, markdown)
+		for _, p := range pieces[:len(pieces)-1] {
+			if p = strings.Trim(p, "\n"); p != "" {
+				chunks = append(chunks, p)
+			}
+		}
+		cur.WriteString(pieces[len(pieces)-1])
+	}
+	flush()
+	return chunks
+}
+
+// 拆分单个超长的段落或代码块
+func splitBlock(b string, n int, markdown bool) []string {
+	if markdown {
+		if _, ok := codeFence(b); ok {
+			return splitFence(b, n)
+		}
+	}
+
+	var chunks []string
+	var cur strings.Builder
+	for _, line := range splitLines(b) {
+		if cur.Len()+len(line) > n && cur.Len() > 0 {
+			chunks = append(chunks, cur.String())
+			cur.Reset()
+		}
+		if len(line) > n {
+			chunks = append(chunks, splitBytes(line, n)...)
+			continue
+		}
+		cur.WriteString(line)
+	}
+	if cur.Len() > 0 {
+		chunks = append(chunks, cur.String())
+	}
+	return chunks
+}
+
+// 拆分超长的代码块，每段均补全代码块的起止标记
+func splitFence(b string, n int) []string {
+	lines := splitLines(b)
+	open := lines[0]
+	if !strings.HasSuffix(open, "\n") {
+		open += "\n"
+	}
+	marker, _ := codeFence(open)
+	body := lines[1:]
+	if len(body) > 0 {
+		if m, ok := codeFence(body[len(body)-1]); ok && strings.HasPrefix(m, marker) {
+			body = body[:len(body)-1]
+		}
+	}
+
+	budget := n - len(open) - len("\n") - len(marker)
+	if budget <= 0 {
+		return splitBytes(b, n)
+	}
+
+	var chunks []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			chunks = append(chunks, open+strings.TrimSuffix(cur.String(), "\n")+"\n"+marker)
+			cur.Reset()
+		}
+	}
+	for _, line := range body {
+		if cur.Len()+len(line) > budget {
+			flush()
+		}
+		if len(line) > budget {
+			for _, p := range splitBytes(line, budget) {
+				cur.WriteString(p)
+				flush()
+			}
+			continue
+		}
+		cur.WriteString(line)
+	}
+	flush()
+	return chunks
+}
+
+// Markdown按空行分段，代码块整体作为一段
+func markdownBlocks(s string) []string {
+	var blocks []string
+	var cur strings.Builder
+	var fence string
+	for _, line := range splitLines(s) {
+		if fence == "" && cur.Len() > 0 {
+			if _, ok := codeFence(line); ok {
+				blocks = append(blocks, cur.String())
+				cur.Reset()
+			}
+		}
+		cur.WriteString(line)
+
+		m, ok := codeFence(line)
+		switch {
+		case fence == "" && ok:
+			fence = m
+		case fence != "" && ok && strings.HasPrefix(m, fence) && strings.TrimSpace(line) == m:
+			fence = ""
+			blocks = append(blocks, cur.String())
+			cur.Reset()
+		case fence == "" && strings.TrimSpace(line) == "":
+			blocks = append(blocks, cur.String())
+			cur.Reset()
+		}
+	}
+	if cur.Len() > 0 {
+		blocks = append(blocks, cur.String())
+	}
+	return blocks
+}
+
+// 代码块的起止标记(```或~~~)
+func codeFence(line string) (string, bool) {
+	line = strings.TrimLeft(line, " ")
+	for _, c := range []byte{'`', '~'} {
+		i := 0
+		for i < len(line) && line[i] == c {
+			i++
+		}
+		if i >= 3 {
+			return line[:i], true
+		}
+	}
+	return "", false
+}
+
+// 按行拆分，保留换行符
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// 按字节长度拆分，不拆开多字节字符
+func splitBytes(s string, n int) []string {
+	var chunks []string
+	for len(s) > n {
+		i := n
+		for i > 0 && !utf8.RuneStart(s[i]) {
+			i--
+		}
+		if i == 0 {
+			i = n
+		}
+		chunks = append(chunks, s[:i])
+		s = s[i:]
+	}
+	return append(chunks, s)
+}
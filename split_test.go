@@ -0,0 +1,103 @@
+package dingtalk_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/shockerli/dingtalk"
+	"github.com/shockerli/dingtalk/dingtalktest"
+)
+
+func sentMessages(t *testing.T, srv *dingtalktest.Server) []dingtalk.Message {
+	t.Helper()
+	var msgs []dingtalk.Message
+	for _, m := range srv.Messages() {
+		var msg dingtalk.Message
+		if err := m.Decode(&msg); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func TestRobotCustom_SetAutoSplit_Text(t *testing.T) {
+	srv := dingtalktest.NewServer("SECtest")
+	defer srv.Close()
+
+	var lines []string
+	for i := 0; i < 30; i++ {
+		lines = append(lines, fmt.Sprintf("line %02d: 堆栈信息", i))
+	}
+	content := strings.Join(lines, "\n")
+
+	rc := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SetSecret(srv.Secret).SetAutoSplit(100)
+	if err := rc.SendText(content, rc.AtMobiles("19900001111")); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+
+	msgs := sentMessages(t, srv)
+	if len(msgs) < 2 {
+		t.Fatalf("len(Messages()) = %d, want > 1", len(msgs))
+	}
+	var got []string
+	for i, msg := range msgs {
+		suffix := fmt.Sprintf("\n(%d/%d)", i+1, len(msgs))
+		if len(msg.Text.Content) > 100 || !strings.HasSuffix(msg.Text.Content, suffix) {
+			t.Errorf("part %d = %q, want <= 100 bytes ending with %q", i, msg.Text.Content, suffix)
+		}
+		if last := i == len(msgs)-1; (msg.At != nil) != last {
+			t.Errorf("part %d At = %+v", i, msg.At)
+		}
+		got = append(got, strings.TrimSuffix(msg.Text.Content, suffix))
+	}
+	if strings.Join(got, "\n") != content {
+		t.Errorf("joined parts = %q, want %q", strings.Join(got, "\n"), content)
+	}
+}
+
+func TestRobotCustom_SetAutoSplit_Markdown(t *testing.T) {
+	srv := dingtalktest.NewServer("SECtest")
+	defer srv.Close()
+
+	code := strings.Repeat("fmt.Println(\"代码块\")\n", 10)
+	text := "## 标题\n\n" + strings.Repeat("段落内容", 10) + "\n\n```go\n" + code + "```\n\n" + strings.Repeat("字", 100)
+
+	rc := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SetSecret(srv.Secret).SetAutoSplit(120)
+	if err := rc.SendMarkdown("TEST: Split", text); err != nil {
+		t.Fatalf("SendMarkdown() error = %v", err)
+	}
+
+	msgs := sentMessages(t, srv)
+	if len(msgs) < 3 {
+		t.Fatalf("len(Messages()) = %d, want >= 3", len(msgs))
+	}
+	for i, msg := range msgs {
+		md := msg.Markdown
+		if len(md.Text) > 120 || !utf8.ValidString(md.Text) {
+			t.Errorf("part %d = %q, want valid UTF-8 <= 120 bytes", i, md.Text)
+		}
+		if n := strings.Count(md.Text, "```"); n%2 != 0 {
+			t.Errorf("part %d = %q, unbalanced code fence", i, md.Text)
+		}
+		if want := fmt.Sprintf("TEST: Split (%d/%d)", i+1, len(msgs)); md.Title != want {
+			t.Errorf("part %d title = %q, want %q", i, md.Title, want)
+		}
+	}
+}
+
+func TestRobotCustom_SetAutoSplit_Short(t *testing.T) {
+	srv := dingtalktest.NewServer("SECtest")
+	defer srv.Close()
+
+	rc := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SetSecret(srv.Secret).SetAutoSplit(100)
+	if err := rc.SendText("TEST: Short"); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+	msgs := sentMessages(t, srv)
+	if len(msgs) != 1 || msgs[0].Text.Content != "TEST: Short" {
+		t.Errorf("Messages() = %+v, want single unmodified message", msgs)
+	}
+}
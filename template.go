@@ -0,0 +1,254 @@
+package dingtalk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// ErrTemplateNotFound 模板不存在
+var ErrTemplateNotFound = errors.New("消息模板不存在")
+
+// Template 消息模板，Title及Body均为text/template模板
+type Template struct {
+	MsgType string        // 消息类型，支持Text/Markdown/ActionCard，默认Markdown
+	Title   string        // 标题模板，Text消息无需设置
+	Body    string        // 内容模板
+	Options []RobotOption // 默认配置项
+}
+
+// 已解析的模板
+type parsedTemplate struct {
+	msgType string
+	title   *template.Template
+	body    *template.Template
+	options []RobotOption
+}
+
+// TemplateRegistry 消息模板注册表，并发安全
+//
+// 模板中可使用以下函数:
+// * formatTime: 格式化时间，例: {{ .Time | formatTime "2006-01-02 15:04:05" }}
+// * truncate: 按字节截断，不拆开多字节字符，例: {{ .Log | truncate 200 }}
+// * escape: 转义Markdown文本，见 EscapeMarkdown，例: {{ .Log | escape }}
+// * mention: @指定手机号的人，同时设置消息的@人，例: {{ mention "19900001111" }}
+//
+// 示例:
+// 	registry := dingtalk.NewTemplateRegistry()
+// 	err := registry.Register("alert", dingtalk.Template{
+// 		Title: "{{ .Service }}告警",
+// 		Body:  "### {{ .Service }}告警\n\n> {{ .Error | escape }}\n\n{{ mention .Owner }}",
+// 	})
+// 	err = registry.Send(ctx, robot, "alert", data)
+type TemplateRegistry struct {
+	mu        sync.RWMutex
+	funcs     template.FuncMap
+	templates map[string]*parsedTemplate
+}
+
+// NewTemplateRegistry 实例化
+func NewTemplateRegistry() *TemplateRegistry {
+	return &TemplateRegistry{
+		funcs: template.FuncMap{
+			"formatTime": func(layout string, t time.Time) string { return t.Format(layout) },
+			"truncate":   func(n int, s string) string { return truncateBytes(s, n) },
+			"escape":     EscapeMarkdown,
+			"mention":    func(mobiles ...string) string { return mentionText(mobiles) },
+		},
+		templates: map[string]*parsedTemplate{},
+	}
+}
+
+// Funcs 添加模板函数，需在注册模板前调用
+func (r *TemplateRegistry) Funcs(funcs template.FuncMap) *TemplateRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, fn := range funcs {
+		r.funcs[name] = fn
+	}
+	return r
+}
+
+// Register 注册模板，同名模板将被覆盖
+func (r *TemplateRegistry) Register(name string, t Template) error {
+	if t.MsgType == "" {
+		t.MsgType = MsgTypeMarkdown
+	}
+	switch t.MsgType {
+	case MsgTypeText, MsgTypeMarkdown, MsgTypeActionCard:
+	default:
+		return fmt.Errorf("消息模板%s: 不支持%s消息", name, t.MsgType)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pt := &parsedTemplate{msgType: t.MsgType, options: t.Options}
+	var err error
+	if pt.title, err = template.New(name).Funcs(r.funcs).Parse(t.Title); err != nil {
+		return fmt.Errorf("消息模板%s: %w", name, err)
+	}
+	if pt.body, err = template.New(name).Funcs(r.funcs).Parse(t.Body); err != nil {
+		return fmt.Errorf("消息模板%s: %w", name, err)
+	}
+	r.templates[name] = pt
+	return nil
+}
+
+// LoadDir 加载目录下的模板文件，文件名(不含扩展名)作为模板名
+//
+// 文件可以"---"包围的头部设置标题及消息类型，其余内容作为内容模板
+//
+// 示例:
+// 	---
+// 	title: {{ .Service }}告警
+// 	type: markdown
+// 	---
+// 	### {{ .Service }}告警
+//
+// 	> {{ .Error | escape }}
+func (r *TemplateRegistry) LoadDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, f.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		t, err := parseTemplateFile(string(data))
+		if err != nil {
+			return fmt.Errorf("消息模板%s: %w", path, err)
+		}
+		name := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+		if err = r.Register(name, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render 渲染模板，opts追加在模板的默认配置项之后
+func (r *TemplateRegistry) Render(name string, data interface{}, opts ...RobotOption) (*Message, error) {
+	r.mu.RLock()
+	pt, ok := r.templates[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+
+	var mentions []string
+	title, err := executeTemplate(pt.title, data, &mentions)
+	if err != nil {
+		return nil, fmt.Errorf("消息模板%s: %w", name, err)
+	}
+	body, err := executeTemplate(pt.body, data, &mentions)
+	if err != nil {
+		return nil, fmt.Errorf("消息模板%s: %w", name, err)
+	}
+
+	var msg *Message
+	switch pt.msgType {
+	case MsgTypeText:
+		msg = NewTextMessage(body)
+	case MsgTypeMarkdown:
+		msg = NewMarkdownMessage(title, body)
+	default:
+		msg = NewActionCardMessage(title, body)
+	}
+	msg.With(pt.options...).With(opts...)
+
+	if len(mentions) > 0 && msg.MsgType != MsgTypeActionCard {
+		if msg.At == nil {
+			msg.At = &RobotAt{}
+		}
+		msg.At.AtMobiles = appendUnique(msg.At.AtMobiles, mentions...)
+	}
+	return msg, nil
+}
+
+// Send 渲染模板并通过sender发送，sender可以是 RobotCustom、RobotPool 等
+func (r *TemplateRegistry) Send(ctx context.Context, sender Sender, name string, data interface{}, opts ...RobotOption) error {
+	msg, err := r.Render(name, data, opts...)
+	if err != nil {
+		return err
+	}
+	return sender.Send(ctx, msg)
+}
+
+// 执行模板，并收集 mention 函数@的手机号
+func executeTemplate(t *template.Template, data interface{}, mentions *[]string) (string, error) {
+	t, err := t.Clone()
+	if err != nil {
+		return "", err
+	}
+	t.Funcs(template.FuncMap{
+		"mention": func(mobiles ...string) string {
+			*mentions = appendUnique(*mentions, mobiles...)
+			return mentionText(mobiles)
+		},
+	})
+
+	var b bytes.Buffer
+	if err = t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// @手机号的文本
+func mentionText(mobiles []string) string {
+	s := make([]string, 0, len(mobiles))
+	for _, m := range mobiles {
+		s = append(s, "@"+m)
+	}
+	return strings.Join(s, " ")
+}
+
+// 解析模板文件的头部
+func parseTemplateFile(s string) (Template, error) {
+	var t Template
+	lines := strings.SplitAfter(strings.Replace(s, "\r\n", "\n", -1), "\n")
+	if strings.TrimSpace(lines[0]) != "---" {
+		t.Body = s
+		return t, nil
+	}
+
+	for i, line := range lines[1:] {
+		if strings.TrimSpace(line) == "---" {
+			t.Body = strings.Join(lines[i+2:], "")
+			return t, nil
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		j := strings.Index(line, ":")
+		if j < 0 {
+			return t, fmt.Errorf("头部格式错误: %s", strings.TrimSpace(line))
+		}
+		key, value := strings.TrimSpace(line[:j]), strings.TrimSpace(line[j+1:])
+		switch key {
+		case "title":
+			t.Title = value
+		case "type":
+			t.MsgType = value
+		default:
+			return t, fmt.Errorf("头部不支持%s", key)
+		}
+	}
+	return t, errors.New("头部缺少结束标记---")
+}
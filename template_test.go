@@ -0,0 +1,137 @@
+package dingtalk_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shockerli/dingtalk"
+	"github.com/shockerli/dingtalk/dingtalktest"
+)
+
+type alert struct {
+	Service string
+	Error   string
+	Owner   string
+	Time    time.Time
+}
+
+var testAlert = alert{
+	Service: "order-api",
+	Error:   "**panic**: <nil>",
+	Owner:   "19900001111",
+	Time:    time.Date(2021, 1, 22, 8, 30, 0, 0, time.UTC),
+}
+
+func TestTemplateRegistry_Render(t *testing.T) {
+	registry := dingtalk.NewTemplateRegistry()
+	err := registry.Register("alert", dingtalk.Template{
+		Title: "{{ .Service }}告警",
+		Body: "### {{ .Service }}告警\n\n" +
+			"> {{ .Error | escape }}\n\n" +
+			"{{ .Time | formatTime \"2006-01-02 15:04\" }} {{ .Service | truncate 6 }} {{ mention .Owner }}",
+		Options: []dingtalk.RobotOption{robot.AtMobiles("19900002222")},
+	})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	msg, err := registry.Render("alert", testAlert)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if msg.MsgType != dingtalk.MsgTypeMarkdown || msg.Markdown.Title != "order-api告警" {
+		t.Errorf("Render() = %+v", msg.Markdown)
+	}
+	want := "### order-api告警\n\n> \\*\\*panic\\*\\*: &lt;nil&gt;\n\n2021-01-22 08:30 ord... @19900001111"
+	if msg.Markdown.Text != want {
+		t.Errorf("Render() text = %q, want %q", msg.Markdown.Text, want)
+	}
+	if got := msg.At.AtMobiles; !reflect.DeepEqual(got, []string{"19900002222", "19900001111"}) {
+		t.Errorf("Render() AtMobiles = %v", got)
+	}
+
+	if _, err = registry.Render("missing", nil); !errors.Is(err, dingtalk.ErrTemplateNotFound) {
+		t.Errorf("Render() error = %v, want ErrTemplateNotFound", err)
+	}
+	if err = registry.Register("bad", dingtalk.Template{Body: "{{ .Service "}); err == nil {
+		t.Error("Register() error = nil, want parse error")
+	}
+	if err = registry.Register("link", dingtalk.Template{MsgType: dingtalk.MsgTypeLink}); err == nil {
+		t.Error("Register() error = nil, want unsupported type")
+	}
+}
+
+func TestTemplateRegistry_LoadDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dingtalk-template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"alert.tmpl": "---\ntitle: {{ .Service }}告警\ntype: actionCard\n---\n### {{ .Service }}\n\n---\n\n{{ .Error }}",
+		"plain.tmpl": "---\ntype: text\n---\n{{ .Service }}: {{ .Error }}",
+		"raw.md":     "## {{ .Service }}",
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry := dingtalk.NewTemplateRegistry()
+	if err = registry.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+
+	msg, err := registry.Render("alert", testAlert)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if msg.ActionCard == nil || msg.ActionCard.Title != "order-api告警" || msg.ActionCard.Text != "### order-api\n\n---\n\n**panic**: <nil>" {
+		t.Errorf("Render(alert) = %+v", msg.ActionCard)
+	}
+	if msg, err = registry.Render("plain", testAlert); err != nil || msg.Text.Content != "order-api: **panic**: <nil>" {
+		t.Errorf("Render(plain) = %+v, %v", msg, err)
+	}
+	if msg, err = registry.Render("raw", testAlert); err != nil || msg.Markdown.Text != "## order-api" {
+		t.Errorf("Render(raw) = %+v, %v", msg, err)
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(dir, "broken.tmpl"), []byte("---\nfoo: bar\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = registry.LoadDir(dir); err == nil || !strings.Contains(err.Error(), "foo") {
+		t.Errorf("LoadDir() error = %v, want unsupported header", err)
+	}
+}
+
+func TestTemplateRegistry_Send(t *testing.T) {
+	srv := dingtalktest.NewServer("SECtest")
+	defer srv.Close()
+
+	registry := dingtalk.NewTemplateRegistry()
+	if err := registry.Register("plain", dingtalk.Template{MsgType: dingtalk.MsgTypeText, Body: "{{ .Service }} {{ mention .Owner }}"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	rc := dingtalk.NewRobotCustom().SetWebhook(srv.Webhook()).SetSecret(srv.Secret)
+	if err := registry.Send(context.Background(), rc, "plain", testAlert); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	var msg dingtalk.Message
+	if err := srv.Messages()[0].Decode(&msg); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if msg.Text.Content != "order-api @19900001111" || msg.At == nil || !reflect.DeepEqual(msg.At.AtMobiles, []string{"19900001111"}) {
+		t.Errorf("sent = %+v %+v", msg.Text, msg.At)
+	}
+}
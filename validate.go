@@ -0,0 +1,173 @@
+package dingtalk
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// 消息限制
+const (
+	maxActionCardBtns = 5  // ActionCard最多按钮数
+	maxFeedCardLinks  = 10 // FeedCard最多条目数
+)
+
+// 消息中允许的链接协议
+var allowedSchemes = map[string]bool{
+	"http":     true,
+	"https":    true,
+	"dingtalk": true, // 钉钉客户端内打开，如 dingtalk://dingtalkclient/page/link?url=xxx
+}
+
+// ValidationError 消息校验失败，包含所有不符合要求的项
+type ValidationError struct {
+	Violations []string
+}
+
+func (e *ValidationError) Error() string {
+	return "群机器人消息校验失败: " + strings.Join(e.Violations, "; ")
+}
+
+// Validate 按群机器人的限制校验消息，返回 *ValidationError 列出所有不符合要求的项
+//
+// 示例:
+// 	if err := msg.Validate(); err != nil {
+// 		log.Println(err)
+// 	}
+func (msg *Message) Validate() error {
+	v := &validator{}
+
+	switch msg.MsgType {
+	case MsgTypeText:
+		if v.require(msg.Text != nil, "text: 缺少消息体") {
+			v.content("text.content", msg.Text.Content)
+		}
+	case MsgTypeLink:
+		if v.require(msg.Link != nil, "link: 缺少消息体") {
+			v.nonEmpty("link.title", msg.Link.Title)
+			v.content("link.text", msg.Link.Text)
+			v.url("link.messageUrl", msg.Link.MessageURL, true)
+			v.url("link.picUrl", msg.Link.PicURL, false)
+		}
+	case MsgTypeMarkdown:
+		if v.require(msg.Markdown != nil, "markdown: 缺少消息体") {
+			v.nonEmpty("markdown.title", msg.Markdown.Title)
+			v.content("markdown.text", msg.Markdown.Text)
+		}
+	case MsgTypeActionCard:
+		if v.require(msg.ActionCard != nil, "actionCard: 缺少消息体") {
+			v.actionCard(msg.ActionCard)
+		}
+	case MsgTypeFeedCard:
+		if v.require(msg.FeedCard != nil, "feedCard: 缺少消息体") {
+			v.feedCard(msg.FeedCard)
+		}
+	default:
+		v.addf("msgtype: 不支持的消息类型 %q", msg.MsgType)
+	}
+
+	if msg.At != nil {
+		for i, m := range msg.At.AtMobiles {
+			v.nonEmpty(fmt.Sprintf("at.atMobiles[%d]", i), m)
+		}
+		for i, id := range msg.At.AtUserIDs {
+			v.nonEmpty(fmt.Sprintf("at.atUserIds[%d]", i), id)
+		}
+	}
+
+	return v.err()
+}
+
+// 收集校验错误
+type validator struct {
+	violations []string
+}
+
+func (v *validator) addf(format string, args ...interface{}) {
+	v.violations = append(v.violations, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) require(ok bool, violation string) bool {
+	if !ok {
+		v.violations = append(v.violations, violation)
+	}
+	return ok
+}
+
+func (v *validator) nonEmpty(field, s string) {
+	if strings.TrimSpace(s) == "" {
+		v.addf("%s: 不能为空", field)
+	}
+}
+
+func (v *validator) content(field, s string) {
+	v.nonEmpty(field, s)
+	if len(s) > maxContentBytes {
+		v.addf("%s: 长度%d字节，超过%d字节的限制", field, len(s), maxContentBytes)
+	}
+}
+
+func (v *validator) url(field, s string, required bool) {
+	if s == "" {
+		if required {
+			v.addf("%s: 不能为空", field)
+		}
+		return
+	}
+	u, err := url.Parse(s)
+	if err != nil || !allowedSchemes[strings.ToLower(u.Scheme)] || (u.Scheme != "dingtalk" && u.Host == "") {
+		v.addf("%s: 无效的链接 %q，仅支持http/https/dingtalk协议", field, s)
+	}
+}
+
+func (v *validator) actionCard(card *RobotActionCard) {
+	v.nonEmpty("actionCard.title", card.Title)
+	v.content("actionCard.text", card.Text)
+
+	single := card.SingleTitle != "" || card.SingleURL != ""
+	switch {
+	case single && len(card.Btns) > 0:
+		v.addf("actionCard: 整体跳转(singleTitle/singleURL)与独立跳转(btns)不能同时设置")
+	case single:
+		v.nonEmpty("actionCard.singleTitle", card.SingleTitle)
+		v.url("actionCard.singleURL", card.SingleURL, true)
+	case len(card.Btns) == 0:
+		v.addf("actionCard: 需设置整体跳转(singleTitle/singleURL)或独立跳转(btns)")
+	}
+
+	if len(card.Btns) > maxActionCardBtns {
+		v.addf("actionCard.btns: 按钮数%d，超过%d个的限制", len(card.Btns), maxActionCardBtns)
+	}
+	for i, btn := range card.Btns {
+		v.nonEmpty(fmt.Sprintf("actionCard.btns[%d].title", i), btn.Title)
+		v.url(fmt.Sprintf("actionCard.btns[%d].actionURL", i), btn.ActionURL, true)
+	}
+
+	if card.HideAvatar != "" && card.HideAvatar != "0" && card.HideAvatar != "1" {
+		v.addf("actionCard.hideAvatar: 仅支持0或1，当前为%q", card.HideAvatar)
+	}
+	if card.BtnOrientation != "" && card.BtnOrientation != "0" && card.BtnOrientation != "1" {
+		v.addf("actionCard.btnOrientation: 仅支持0或1，当前为%q", card.BtnOrientation)
+	}
+}
+
+func (v *validator) feedCard(feed *RobotFeedCard) {
+	switch n := len(feed.Links); {
+	case n == 0:
+		v.addf("feedCard.links: 不能为空")
+	case n > maxFeedCardLinks:
+		v.addf("feedCard.links: 条目数%d，超过%d个的限制", n, maxFeedCardLinks)
+	}
+	for i, link := range feed.Links {
+		v.nonEmpty(fmt.Sprintf("feedCard.links[%d].title", i), link.Title)
+		v.url(fmt.Sprintf("feedCard.links[%d].messageURL", i), link.MessageURL, true)
+		v.url(fmt.Sprintf("feedCard.links[%d].picURL", i), link.PicURL, true)
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: v.violations}
+}
@@ -0,0 +1,65 @@
+package dingtalk_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shockerli/dingtalk"
+)
+
+func TestMessage_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		msg        *dingtalk.Message
+		violations int
+	}{
+		{"Text", dingtalk.NewTextMessage("TEST: Text"), 0},
+		{"TextEmpty", dingtalk.NewTextMessage(" "), 1},
+		{"TextTooLong", dingtalk.NewTextMessage(strings.Repeat("a", 20001)), 1},
+		{"LinkInvalidURL", dingtalk.NewLinkMessage("title", "text", "javascript:alert(1)", "ftp://example.com/a.png"), 2},
+		{"ActionCardBoth", dingtalk.NewActionCardMessage("title", "text").With(
+			robot.SingleCard("阅读全文", "https://github.com/shockerli"),
+			robot.MultiCard("内容不错", "https://github.com/shockerli"),
+		), 1},
+		{"AtEmpty", dingtalk.NewTextMessage("TEST: Text").With(robot.AtMobiles(""), robot.AtUserIDs("manager1234", "")), 2},
+		{"ActionCardNone", dingtalk.NewActionCardMessage("title", "text"), 1},
+		{"FeedCardEmpty", dingtalk.NewFeedCardMessage(), 1},
+		{"FeedCard", dingtalk.NewFeedCardMessage().With(
+			robot.FeedCard("title", "dingtalk://dingtalkclient/page/link?url=https%3A%2F%2Fgithub.com", "https://github.com/shockerli.png"),
+		), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.msg.Validate()
+			if tt.violations == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var ve *dingtalk.ValidationError
+			if !errors.As(err, &ve) || len(ve.Violations) != tt.violations {
+				t.Errorf("Validate() error = %v, want %d violations", err, tt.violations)
+			}
+		})
+	}
+}
+
+func TestRobotCustom_SetValidation(t *testing.T) {
+	rc := dingtalk.NewRobotCustom().
+		SetWebhook("https://oapi.dingtalk.com/robot/send?access_token=test").
+		SetTransport(okTransport())
+
+	var ve *dingtalk.ValidationError
+	if err := rc.SendFeedCard(); !errors.As(err, &ve) {
+		t.Errorf("SendFeedCard() error = %v, want *ValidationError", err)
+	}
+
+	rc.SetValidation(false)
+	if err := rc.SendFeedCard(); err != nil {
+		t.Errorf("SendFeedCard() without validation error = %v", err)
+	}
+}